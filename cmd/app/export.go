@@ -0,0 +1,116 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"log"
+	"mr-metrics/internal/dates"
+	"mr-metrics/internal/db"
+	"mr-metrics/internal/export"
+	"mr-metrics/internal/model"
+	"os"
+)
+
+// statsPeriod selects the stats the same way as the query parameters of the web UI:
+// up to the date, merged inside the range or up to today.
+type statsPeriod struct {
+	date string
+	from string
+	to   string
+}
+
+type statsWriter func(w io.Writer, data *model.AggregatedStats) error
+
+// runExport prints the developer × repository table as CSV or JSON.
+func runExport(ctx context.Context, args []string, opts options) int {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	period := addPeriodFlags(flags)
+	format := flags.String("format", "csv", "output format: csv or json")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	var write statsWriter
+	switch *format {
+	case "csv":
+		write = export.WriteCSV
+	case "json":
+		write = export.WriteJSON
+	default:
+		log.Printf("Unsupported format %q, use csv or json", *format)
+		return exitUsage
+	}
+
+	return printStats(ctx, period, opts, write)
+}
+
+// runReport prints the developer × repository table aligned for a terminal.
+func runReport(ctx context.Context, args []string, opts options) int {
+	flags := flag.NewFlagSet("report", flag.ContinueOnError)
+	period := addPeriodFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	return printStats(ctx, period, opts, export.WriteText)
+}
+
+func addPeriodFlags(flags *flag.FlagSet) *statsPeriod {
+	period := &statsPeriod{}
+	flags.StringVar(&period.date, "date", "", "count merged requests up to the date (YYYY-MM-DD)")
+	flags.StringVar(&period.from, "from", "", "count only merged requests merged since the date (YYYY-MM-DD)")
+	flags.StringVar(&period.to, "to", "", "count only merged requests merged till the date (YYYY-MM-DD), today by default")
+	return period
+}
+
+func printStats(ctx context.Context, period *statsPeriod, opts options, write statsWriter) int {
+	_, store, err := openStore(ctx, opts)
+	if err != nil {
+		log.Print(err)
+		return exitFailure
+	}
+	defer store.Close()
+
+	data, err := period.stats(ctx, store)
+	if err != nil {
+		log.Printf("Failed to get stats: %v", err)
+		return exitFailure
+	}
+
+	if err := write(os.Stdout, data); err != nil {
+		log.Printf("Failed to write stats: %v", err)
+		return exitFailure
+	}
+	return 0
+}
+
+// stats returns the stats of the tracked projects for the period.
+func (p *statsPeriod) stats(ctx context.Context, store db.Store) (*model.AggregatedStats, error) {
+	period, err := dates.ParsePeriod(p.date, p.from, p.to)
+	if err != nil {
+		return nil, err
+	}
+
+	projectNames, err := store.GetTrackedProjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var data *model.AggregatedStats
+	if period.IsRange {
+		data, err = store.GetAggregatedDataForRange(ctx, projectNames, dates.StartOfDay(period.From), dates.EndOfDay(period.To))
+	} else {
+		data, err = store.GetAggregatedDataForDate(ctx, projectNames, dates.EndOfDay(period.To))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data.DateString, data.DateFromString, data.DateToString = period.Labels()
+	return data, nil
+}
@@ -6,33 +6,186 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
 	"log"
 	"mr-metrics/internal/api"
 	"mr-metrics/internal/config"
 	"mr-metrics/internal/db"
+	"mr-metrics/internal/demo"
 	"mr-metrics/internal/handlers"
 	"mr-metrics/internal/service/updater"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	_ "github.com/lib/pq"
 )
 
+const (
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+// options are the flags shared by all commands.
+type options struct {
+	demo       bool
+	configFile string
+}
+
 func main() {
-	ctx := context.Background()
+	var opts options
+	flag.BoolVar(&opts.demo, "demo", false, "use a year of synthetic history kept in memory instead of the database")
+	flag.StringVar(&opts.configFile, "config", "", "path of a YAML or TOML config file, CONFIG_FILE by default")
+	flag.Usage = usage
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	command, args := flag.Arg(0), flag.Args()
+	if len(args) > 0 {
+		args = args[1:]
+	}
+
+	var exitCode int
+	switch command {
+	case "":
+		exitCode = runAll(ctx, opts)
+	case "serve":
+		exitCode = runServe(ctx, opts)
+	case "sync":
+		exitCode = runSync(ctx, args, opts)
+	case "export":
+		exitCode = runExport(ctx, args, opts)
+	case "report":
+		exitCode = runReport(ctx, args, opts)
+	case "migrate":
+		exitCode = runMigrate(args, opts)
+	default:
+		usage()
+		exitCode = exitUsage
+	}
+
+	stop()
+	os.Exit(exitCode)
+}
 
-	cfg, err := config.Load()
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [--demo] [--config FILE] [command]\n\n", os.Args[0])
+	fmt.Fprintf(out, "Commands:\n")
+	fmt.Fprintf(out, "  (none)        serve the stats and sync GitLab in the background\n")
+	fmt.Fprintf(out, "  serve         serve the stats without syncing GitLab\n")
+	fmt.Fprintf(out, "  sync          sync all or the given projects once, fails if any of them failed\n")
+	fmt.Fprintf(out, "  export        print the stats as CSV or JSON\n")
+	fmt.Fprintf(out, "  report        print the stats as a table\n")
+	fmt.Fprintf(out, "  migrate       manage the database schema: up, down N, version, force VERSION\n")
+	fmt.Fprintf(out, "\nRun \"%s COMMAND --help\" for the flags of a command.\n\nFlags:\n", os.Args[0])
+	flag.PrintDefaults()
+}
+
+// runAll serves the stats and keeps projects up to date.
+func runAll(ctx context.Context, opts options) int {
+	if opts.demo {
+		return runServe(ctx, opts)
+	}
+
+	cfg, err := config.Load(opts.configFile)
+	if err != nil {
+		log.Printf("Failed to load config: %v", err)
+		return exitFailure
+	}
+
+	store, err := db.Open(cfg.DatabaseURL, cfg.ExcludeReverts, cfg.AutoMigrate)
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		log.Printf("Failed to connect to database: %v", err)
+		return exitFailure
+	}
+
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	live := config.NewLive(cfg, config.Load)
+	go live.Watch(ctx)
+
+	u := updater.New(store, api.NewSourcesClient(cfg), live)
+	u.Start(ctx)
+
+	exitCode := 0
+	if err := handlers.Start(ctx, store, u, live); err != nil {
+		log.Printf("HTTP server failed: %v", err)
+		exitCode = exitFailure
+	}
+
+	// Stop the updater if the server failed on its own and wait for the current update to roll back
+	stop()
+	u.Wait()
+
+	if err := store.Close(); err != nil {
+		log.Printf("Failed to close database: %v", err)
+		exitCode = exitFailure
 	}
 
-	gitlabClient := api.NewGitLabClient(cfg)
+	log.Printf("Stopped")
+	return exitCode
+}
 
-	store, err := db.NewPostgresStore(cfg.DatabaseURL)
+// runServe serves the stats, projects are synced by another instance or by the sync command.
+func runServe(ctx context.Context, opts options) int {
+	cfg, store, err := openStore(ctx, opts)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		log.Print(err)
+		return exitFailure
 	}
+	go cfg.Watch(ctx)
 
-	u := updater.New(store, gitlabClient, cfg)
-	go u.Start(ctx)
+	// Syncs requested here are run by an instance syncing projects if the database is shared with it
+	var trigger handlers.SyncTrigger
+	if queue, ok := store.(updater.SyncQueue); ok {
+		trigger = updater.NewQueue(queue)
+	}
+
+	exitCode := 0
+	if err := handlers.Start(ctx, store, trigger, cfg); err != nil {
+		log.Printf("HTTP server failed: %v", err)
+		exitCode = exitFailure
+	}
+
+	if err := store.Close(); err != nil {
+		log.Printf("Failed to close database: %v", err)
+		exitCode = exitFailure
+	}
 
-	log.Fatal(handlers.Start(store, cfg))
+	log.Printf("Stopped")
+	return exitCode
+}
+
+// openStore opens the database for the commands that only read the stats.
+// In the demo mode the store is kept in memory and filled with synthetic data.
+func openStore(ctx context.Context, opts options) (*config.Live, db.Store, error) {
+	if opts.demo {
+		cfg, err := config.LoadDemo(opts.configFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load config: %w", err)
+		}
+
+		store := db.NewMemoryStore(cfg.ExcludeReverts)
+		if err := demo.Seed(ctx, store, time.Now()); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate demo data: %w", err)
+		}
+		return config.NewLive(cfg, config.LoadDemo), store, nil
+	}
+
+	cfg, err := config.LoadDatabase(opts.configFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	store, err := db.Open(cfg.DatabaseURL, cfg.ExcludeReverts, cfg.AutoMigrate)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	return config.NewLive(cfg, config.LoadDatabase), store, nil
 }
@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"mr-metrics/internal/config"
+	"mr-metrics/internal/db"
+	"os"
+	"strconv"
+)
+
+// runMigrate manages the schema of DATABASE_URL and returns the exit code.
+func runMigrate(args []string, opts options) int {
+	if len(args) == 0 {
+		migrateUsage()
+		return exitUsage
+	}
+
+	command, args := args[0], args[1:]
+	wantArgs := 0
+	if command == "down" || command == "force" {
+		wantArgs = 1
+	}
+	if len(args) != wantArgs {
+		migrateUsage()
+		return exitUsage
+	}
+
+	var number int
+	if wantArgs == 1 {
+		var err error
+		number, err = strconv.Atoi(args[0])
+		if err != nil || number < 0 || command == "down" && number == 0 {
+			log.Printf("Invalid %s argument %q", command, args[0])
+			return exitUsage
+		}
+	}
+
+	cfg, err := config.LoadDatabase(opts.configFile)
+	if err != nil {
+		log.Printf("Failed to load config: %v", err)
+		return exitFailure
+	}
+
+	migrator, err := db.OpenMigrator(cfg.DatabaseURL)
+	if err != nil {
+		log.Printf("Failed to connect to database: %v", err)
+		return exitFailure
+	}
+	defer migrator.Close()
+
+	switch command {
+	case "up":
+		err = migrator.Up()
+	case "down":
+		err = migrator.Down(number)
+	case "force":
+		err = migrator.Force(number)
+	case "version":
+	default:
+		migrateUsage()
+		return exitUsage
+	}
+	if err != nil {
+		log.Printf("Migration failed: %v", err)
+		return exitFailure
+	}
+
+	version, dirty, err := migrator.Version()
+	if err != nil {
+		log.Printf("Failed to get schema version: %v", err)
+		return exitFailure
+	}
+
+	if dirty {
+		log.Printf("Schema version %d (dirty, the migration failed)", version)
+	} else {
+		log.Printf("Schema version %d", version)
+	}
+	return 0
+}
+
+func migrateUsage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage:\n")
+	fmt.Fprintf(out, "  %s migrate up            apply all migrations\n", os.Args[0])
+	fmt.Fprintf(out, "  %s migrate down N        roll back N latest migrations\n", os.Args[0])
+	fmt.Fprintf(out, "  %s migrate version       print the schema version\n", os.Args[0])
+	fmt.Fprintf(out, "  %s migrate force VERSION mark the schema as migrated to VERSION without running migrations\n", os.Args[0])
+}
@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"mr-metrics/internal/api"
+	"mr-metrics/internal/config"
+	"mr-metrics/internal/dates"
+	"mr-metrics/internal/db"
+	"mr-metrics/internal/model"
+	"mr-metrics/internal/service/updater"
+	"os"
+)
+
+// runSync syncs all projects or the given ones once, so syncs can be scheduled outside of the application,
+// e.g. by a Kubernetes CronJob. It fails if any of the projects failed to sync.
+func runSync(ctx context.Context, args []string, opts options) int {
+	flags := flag.NewFlagSet("sync", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s sync [--since YYYY-MM-DD] [--rebuild] [PROJECT...]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	sinceStr := flags.String("since", "", "fetch merge requests updated since the date instead of the last update")
+	rebuild := flags.Bool("rebuild", false, "replace the stored merge requests merged since --since (or all of them)")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	request := model.SyncRequest{Rebuild: *rebuild}
+	if *sinceStr != "" {
+		since, err := dates.Parse(*sinceStr)
+		if err != nil {
+			log.Printf("Invalid --since: %v", err)
+			return exitUsage
+		}
+		request.Since = since
+	}
+
+	cfg, err := config.Load(opts.configFile)
+	if err != nil {
+		log.Printf("Failed to load config: %v", err)
+		return exitFailure
+	}
+
+	store, err := db.Open(cfg.DatabaseURL, cfg.ExcludeReverts, cfg.AutoMigrate)
+	if err != nil {
+		log.Printf("Failed to connect to database: %v", err)
+		return exitFailure
+	}
+	defer store.Close()
+
+	u := updater.New(store, api.NewSourcesClient(cfg), config.Static(cfg))
+
+	projectNames := flags.Args()
+	if len(projectNames) == 0 {
+		// An empty name stands for all projects
+		projectNames = []string{""}
+	}
+
+	exitCode := 0
+	syncProjects := func(ctx context.Context) {
+		for _, projectName := range projectNames {
+			request.ProjectName = projectName
+			if err := u.Sync(ctx, request); err != nil {
+				exitCode = exitFailure
+			}
+		}
+	}
+
+	// NOTE(danilax86): The sync takes the leader lock, so it never writes together with
+	// an instance syncing in the background or with another scheduled sync.
+	if elector, ok := store.(updater.Elector); ok {
+		led, err := elector.TryLead(ctx, syncProjects)
+		if err != nil {
+			log.Printf("Failed to acquire sync lock: %v", err)
+			return exitFailure
+		}
+		if !led {
+			log.Printf("Projects are being synced by another instance, sync skipped")
+			return exitFailure
+		}
+	} else {
+		syncProjects(ctx)
+	}
+
+	if exitCode != 0 {
+		log.Printf("Sync failed")
+	} else {
+		log.Printf("Sync finished")
+	}
+	return exitCode
+}
@@ -5,21 +5,32 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"mr-metrics/internal/config"
 	"mr-metrics/internal/model"
 	"net/http"
 	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
+
+	"golang.org/x/time/rate"
 )
 
 const (
 	defaultTimeout = 15 * time.Second
+
+	// Beginning of the system note GitLab leaves on approving a merge request
+	approvedNote = "approved this merge request"
 )
 
+// addedCommitsNote matches the system note GitLab leaves on pushing commits to a merge request,
+// other notes start with "added" as well, e.g. "added ~bug label".
+var addedCommitsNote = regexp.MustCompile(`^added \d+ commits?\b`)
+
 type GitLabClient struct {
 	token   string
 	client  *http.Client
@@ -30,66 +41,320 @@ type ProjectMRResponse struct {
 	Author struct {
 		Username string `json:"username"`
 	} `json:"author"`
-	ProjectID int        `json:"project_id"`
-	MergedAt  *time.Time `json:"merged_at"`
+	ProjectID    int        `json:"project_id"`
+	IID          int        `json:"iid"`
+	Title        string     `json:"title"`
+	SourceBranch string     `json:"source_branch"`
+	TargetBranch string     `json:"target_branch"`
+	Labels       []string   `json:"labels"`
+	WebURL       string     `json:"web_url"`
+	CreatedAt    time.Time  `json:"created_at"`
+	MergedAt     *time.Time `json:"merged_at"`
+}
+
+type ProjectResponse struct {
+	ID                int    `json:"id"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	Archived          bool   `json:"archived"`
+}
+
+type NoteResponse struct {
+	Body   string `json:"body"`
+	System bool   `json:"system"`
+	Author struct {
+		Username string `json:"username"`
+	} `json:"author"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ApprovalsResponse struct {
+	ApprovedBy []struct {
+		User struct {
+			Username string `json:"username"`
+		} `json:"user"`
+	} `json:"approved_by"`
+}
+
+type MRDetailsResponse struct {
+	// Amount of changed files, e.g. "12" or "1000+"
+	ChangesCount string `json:"changes_count"`
 }
 
+// NewGitLabClient returns the client of the default GitLab source.
 func NewGitLabClient(cfg *config.Config) *GitLabClient {
+	return newGitLabClient(cfg.GitLabHostURL, cfg.GitLabToken, cfg)
+}
+
+// newGitLabClient returns the client of a GitLab instance, every instance has its own rate limit.
+func newGitLabClient(hostURL, token string, cfg *config.Config) *GitLabClient {
 	return &GitLabClient{
-		token: cfg.GitLabToken,
+		token: token,
 		client: &http.Client{
-			Timeout: defaultTimeout,
+			Transport: newRetryTransport(
+				http.DefaultTransport, newRateLimiter(cfg.GitLabRateLimit), cfg.GitLabMaxRetries, defaultTimeout,
+			),
 		},
-		baseURL: strings.TrimSuffix(cfg.GitLabHostURL, "/") + "/api/v4",
+		baseURL: strings.TrimSuffix(hostURL, "/") + "/api/v4",
 	}
 }
 
-// GetMergedMRCounts returns merged MR counts per user for a project.
-func (g *GitLabClient) GetMergedMRCounts(projectName string, since time.Time) ([]model.MergeRequest, int, error) {
-	var (
-		mrs       []model.MergeRequest
-		projectID int
-		page      = 1
-	)
+// newRateLimiter returns a limiter allowing the given amount of requests per second,
+// 0 means unlimited.
+func newRateLimiter(requestsPerSecond float64) *rate.Limiter {
+	if requestsPerSecond <= 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+	return rate.NewLimiter(rate.Limit(requestsPerSecond), 1)
+}
 
-	for {
-		endpointURL := g.getMergeRequestsEndpointURL(projectName, since, page)
-		resp, err := g.sendGetRequest(endpointURL)
+// GetMergedMRCounts returns merged MRs of a project that were updated since the given time.
+func (g *GitLabClient) GetMergedMRCounts(ctx context.Context, projectName string, since time.Time) ([]model.MergeRequest, int, error) {
+	apiMRs, err := getAllPages[ProjectMRResponse](ctx, g, func(page int) string {
+		return g.getMergeRequestsEndpointURL(projectName, since, page)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(apiMRs) == 0 {
+		// NOTE(danilax86): The project ID is still needed to store that the project was synced,
+		// e.g. when a rebuild removes all of its merge requests.
+		projectID, err := g.getProjectID(ctx, projectName)
 		if err != nil {
 			return nil, 0, err
 		}
+		return nil, projectID, nil
+	}
 
-		if resp.StatusCode != http.StatusOK {
-			resp.Body.Close()
-			return nil, 0, fmt.Errorf("API returned %d", resp.StatusCode)
+	return g.extractMergeRequests(apiMRs), apiMRs[0].ProjectID, nil
+}
+
+// getProjectID returns the GitLab ID of a project by its full name.
+func (g *GitLabClient) getProjectID(ctx context.Context, projectName string) (int, error) {
+	resp, err := g.sendGetRequest(ctx, fmt.Sprintf("%s/projects/%s", g.baseURL, pathEscape(projectName)))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("API returned %d", resp.StatusCode)
+	}
+
+	var project ProjectResponse
+	if err := json.NewDecoder(resp.Body).Decode(&project); err != nil {
+		return 0, fmt.Errorf("decode failed: %w", err)
+	}
+	return project.ID, nil
+}
+
+// DiscoverProjects returns full names of not archived projects that have any of the topics
+// or belong to any of the groups (including their subgroups).
+func (g *GitLabClient) DiscoverProjects(ctx context.Context, topics, groups []string) ([]string, error) {
+	seen := make(map[string]struct{})
+	var projectNames []string
+
+	collect := func(endpointURL func(page int) string) error {
+		projects, err := g.getProjects(ctx, endpointURL)
+		if err != nil {
+			return err
+		}
+		for _, project := range projects {
+			if project.Archived {
+				continue
+			}
+			if _, exists := seen[project.PathWithNamespace]; exists {
+				continue
+			}
+			seen[project.PathWithNamespace] = struct{}{}
+			projectNames = append(projectNames, project.PathWithNamespace)
 		}
+		return nil
+	}
 
-		apiMRs, err := g.decodeMergeRequests(resp.Body)
+	// NOTE(danilax86): GitLab treats several topics in one request as "all of them",
+	// so every topic is requested separately.
+	for _, topic := range topics {
+		err := collect(func(page int) string {
+			return fmt.Sprintf(
+				"%s/projects?topic=%s&archived=false&simple=true&page=%d&per_page=100",
+				g.baseURL, url.QueryEscape(topic), page,
+			)
+		})
 		if err != nil {
-			resp.Body.Close()
-			return nil, 0, err
+			return nil, fmt.Errorf("failed to get projects for topic %s: %w", topic, err)
 		}
+	}
 
-		if len(apiMRs) == 0 {
-			resp.Body.Close()
-			break
+	for _, group := range groups {
+		err := collect(func(page int) string {
+			return fmt.Sprintf(
+				"%s/groups/%s/projects?include_subgroups=true&archived=false&simple=true&page=%d&per_page=100",
+				g.baseURL, pathEscape(group), page,
+			)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get projects for group %s: %w", group, err)
+		}
+	}
+
+	return projectNames, nil
+}
+
+func (g *GitLabClient) getProjects(ctx context.Context, endpointURL func(page int) string) ([]ProjectResponse, error) {
+	return getAllPages[ProjectResponse](ctx, g, endpointURL)
+}
+
+// GetMergeRequestReview returns comments and approvals left on a merge request by anyone except its author.
+func (g *GitLabClient) GetMergeRequestReview(
+	ctx context.Context, projectName string, mr model.MergeRequest,
+) (model.MergeRequestReview, error) {
+	notes, err := getAllPages[NoteResponse](ctx, g, func(page int) string {
+		return fmt.Sprintf(
+			"%s/projects/%s/merge_requests/%d/notes?sort=asc&order_by=created_at&page=%d&per_page=100",
+			g.baseURL, pathEscape(projectName), mr.IID, page,
+		)
+	})
+	if err != nil {
+		return model.MergeRequestReview{}, fmt.Errorf("failed to get notes of !%d: %w", mr.IID, err)
+	}
+
+	resp, err := g.sendGetRequest(ctx, fmt.Sprintf(
+		"%s/projects/%s/merge_requests/%d/approvals",
+		g.baseURL, pathEscape(projectName), mr.IID,
+	))
+	if err != nil {
+		return model.MergeRequestReview{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return model.MergeRequestReview{}, fmt.Errorf("API returned %d", resp.StatusCode)
+	}
+
+	var approvals ApprovalsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&approvals); err != nil {
+		return model.MergeRequestReview{}, fmt.Errorf("decode failed: %w", err)
+	}
+
+	return buildReview(mr, notes, approvals), nil
+}
+
+// GetMergeRequestChangesCount returns the amount of files changed by a merge request.
+// GitLab doesn't count changes above its diff limits precisely, so such merge requests get the limit.
+func (g *GitLabClient) GetMergeRequestChangesCount(ctx context.Context, projectName string, iid int) (int, error) {
+	resp, err := g.sendGetRequest(ctx, fmt.Sprintf(
+		"%s/projects/%s/merge_requests/%d",
+		g.baseURL, pathEscape(projectName), iid,
+	))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("API returned %d", resp.StatusCode)
+	}
+
+	var details MRDetailsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&details); err != nil {
+		return 0, fmt.Errorf("decode failed: %w", err)
+	}
+
+	if details.ChangesCount == "" {
+		return 0, nil
+	}
+
+	changesCount, err := strconv.Atoi(strings.TrimSuffix(details.ChangesCount, "+"))
+	if err != nil {
+		return 0, fmt.Errorf("invalid changes count %q: %w", details.ChangesCount, err)
+	}
+	return changesCount, nil
+}
+
+// buildReview collects review events from the notes of a merge request and counts review rounds.
+// A new round starts with the first reviewer's comment or approval after the author commented or pushed commits.
+func buildReview(mr model.MergeRequest, notes []NoteResponse, approvals ApprovalsResponse) model.MergeRequestReview {
+	var review model.MergeRequestReview
+	approved := make(map[string]struct{})
+	awaitingReview := true
+
+	for _, note := range notes {
+		isAuthor := note.Author.Username == mr.Username
+
+		switch {
+		case isAuthor && (!note.System || addedCommitsNote.MatchString(note.Body)):
+			awaitingReview = true
+			continue
+		case isAuthor, note.System && !strings.HasPrefix(note.Body, approvedNote):
+			continue
+		}
+
+		kind := model.ReviewEventComment
+		if note.System {
+			kind = model.ReviewEventApproval
+			approved[note.Author.Username] = struct{}{}
+		}
+
+		review.Events = append(review.Events, model.ReviewEvent{
+			Username:  note.Author.Username,
+			Kind:      kind,
+			CreatedAt: note.CreatedAt,
+		})
+
+		if awaitingReview {
+			review.Rounds++
+			awaitingReview = false
 		}
+	}
 
-		if projectID == 0 {
-			projectID = apiMRs[0].ProjectID
+	// NOTE(danilax86): Approvals don't have timestamps and not every approval has a system note,
+	// such approvals are counted at the merge time but are left out of the time to approval.
+	for _, approver := range approvals.ApprovedBy {
+		username := approver.User.Username
+		if _, exists := approved[username]; exists || username == mr.Username {
+			continue
 		}
+		review.Events = append(review.Events, model.ReviewEvent{
+			Username:  username,
+			Kind:      model.ReviewEventApproval,
+			CreatedAt: mr.MergedAt,
+			Estimated: true,
+		})
+	}
 
-		mrs = append(mrs, g.extractMergeRequests(apiMRs)...)
+	return review
+}
+
+// getAllPages requests all pages of a paginated endpoint.
+// Failed requests are retried by the transport, so a failure doesn't restart the pagination from the first page.
+func getAllPages[T any](ctx context.Context, g *GitLabClient, endpointURL func(page int) string) ([]T, error) {
+	var items []T
 
-		if !g.hasNextPage(resp.Header) {
+	for page := 1; ; page++ {
+		resp, err := g.sendGetRequest(ctx, endpointURL(page))
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode != http.StatusOK {
 			resp.Body.Close()
-			break
+			return nil, fmt.Errorf("API returned %d", resp.StatusCode)
+		}
+
+		var pageItems []T
+		if err := json.NewDecoder(resp.Body).Decode(&pageItems); err != nil {
+			resp.Body.Close()
+			return nil, fmt.Errorf("decode failed: %w", err)
 		}
 		resp.Body.Close()
-		page++
-	}
 
-	return mrs, projectID, nil
+		items = append(items, pageItems...)
+
+		if len(pageItems) == 0 || !g.hasNextPage(resp.Header) {
+			return items, nil
+		}
+	}
 }
 
 func (g *GitLabClient) getMergeRequestsEndpointURL(projectName string, since time.Time, page int) string {
@@ -99,8 +364,8 @@ func (g *GitLabClient) getMergeRequestsEndpointURL(projectName string, since tim
 	)
 }
 
-func (g *GitLabClient) sendGetRequest(endpointURL string) (*http.Response, error) {
-	req, err := http.NewRequest(http.MethodGet, endpointURL, nil)
+func (g *GitLabClient) sendGetRequest(ctx context.Context, endpointURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpointURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("create request failed: %w", err)
 	}
@@ -109,14 +374,6 @@ func (g *GitLabClient) sendGetRequest(endpointURL string) (*http.Response, error
 	return g.client.Do(req)
 }
 
-func (g *GitLabClient) decodeMergeRequests(body io.Reader) ([]ProjectMRResponse, error) {
-	var apiMRs []ProjectMRResponse
-	if err := json.NewDecoder(body).Decode(&apiMRs); err != nil {
-		return nil, fmt.Errorf("decode failed: %w", err)
-	}
-	return apiMRs, nil
-}
-
 func (g *GitLabClient) extractMergeRequests(apiMRs []ProjectMRResponse) []model.MergeRequest {
 	mrs := make([]model.MergeRequest, 0, len(apiMRs))
 	for _, mr := range apiMRs {
@@ -124,8 +381,16 @@ func (g *GitLabClient) extractMergeRequests(apiMRs []ProjectMRResponse) []model.
 			continue
 		}
 		mrs = append(mrs, model.MergeRequest{
-			Username: mr.Author.Username,
-			MergedAt: *mr.MergedAt,
+			ProjectID:    mr.ProjectID,
+			IID:          mr.IID,
+			Title:        mr.Title,
+			Username:     mr.Author.Username,
+			SourceBranch: mr.SourceBranch,
+			TargetBranch: mr.TargetBranch,
+			Labels:       mr.Labels,
+			WebURL:       mr.WebURL,
+			CreatedAt:    mr.CreatedAt,
+			MergedAt:     *mr.MergedAt,
 		})
 	}
 	return mrs
@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"mr-metrics/internal/api"
+	"mr-metrics/internal/config"
+	"mr-metrics/internal/model"
+)
+
+const (
+	author   = "author"
+	reviewer = "reviewer"
+)
+
+type note struct {
+	Body   string `json:"body"`
+	System bool   `json:"system"`
+	Author struct {
+		Username string `json:"username"`
+	} `json:"author"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newNote(username, body string, system bool, createdAt time.Time) note {
+	n := note{Body: body, System: system, CreatedAt: createdAt}
+	n.Author.Username = username
+	return n
+}
+
+type approvals struct {
+	ApprovedBy []approval `json:"approved_by"`
+}
+
+type approval struct {
+	User struct {
+		Username string `json:"username"`
+	} `json:"user"`
+}
+
+func newApprovals(usernames ...string) approvals {
+	a := approvals{ApprovedBy: make([]approval, len(usernames))}
+	for i, username := range usernames {
+		a.ApprovedBy[i].User.Username = username
+	}
+	return a
+}
+
+// newGitLab serves the notes and the approvals of every merge request.
+func newGitLab(t *testing.T, notes []note, approved approvals) *api.GitLabClient {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body any
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/notes"):
+			body = notes
+		case strings.HasSuffix(r.URL.Path, "/approvals"):
+			body = approved
+		default:
+			http.NotFound(w, r)
+			return
+		}
+
+		if err := json.NewEncoder(w).Encode(body); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return api.NewGitLabClient(&config.Config{GitLabHostURL: server.URL, GitLabToken: "token"})
+}
+
+func TestGetMergeRequestReviewRounds(t *testing.T) {
+	t.Parallel()
+
+	// Real system notes GitLab leaves on behalf of the author of a merge request
+	tests := []struct {
+		body       string
+		wantRounds int
+	}{
+		{body: "added ~bug label", wantRounds: 1},
+		{body: "added @x as reviewer", wantRounds: 1},
+		{body: "added to milestone", wantRounds: 1},
+		{body: "added 1 commit\n\n<ul><li>0123abcd - Fix typo</li></ul>", wantRounds: 2},
+		{body: "added 3 commits\n\n<ul><li>0123abcd...4567ef01 - 3 commits from branch <code>main</code></li></ul>", wantRounds: 2},
+		{body: "added 12 commits", wantRounds: 2},
+		{body: "marked this merge request as ready", wantRounds: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.body, func(t *testing.T) {
+			t.Parallel()
+
+			createdAt := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+			gitlab := newGitLab(t, []note{
+				newNote(reviewer, "Please fix the typo", false, createdAt.Add(time.Hour)),
+				newNote(author, tt.body, true, createdAt.Add(2*time.Hour)),
+				newNote(reviewer, "Thanks", false, createdAt.Add(3*time.Hour)),
+			}, newApprovals())
+
+			review, err := gitlab.GetMergeRequestReview(t.Context(), "group/repo", model.MergeRequest{
+				IID: 1, Username: author, CreatedAt: createdAt, MergedAt: createdAt.Add(4 * time.Hour),
+			})
+			if err != nil {
+				t.Fatalf("GetMergeRequestReview failed: %v", err)
+			}
+			if review.Rounds != tt.wantRounds {
+				t.Errorf("Rounds = %d, want %d", review.Rounds, tt.wantRounds)
+			}
+		})
+	}
+}
+
+func TestGetMergeRequestReviewApprovalsWithoutNote(t *testing.T) {
+	t.Parallel()
+
+	createdAt := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	approvedAt := createdAt.Add(time.Hour)
+	mergedAt := createdAt.Add(4 * time.Hour)
+	gitlab := newGitLab(t, []note{
+		newNote(reviewer, "approved this merge request", true, approvedAt),
+	}, newApprovals(reviewer, "silent", author))
+
+	review, err := gitlab.GetMergeRequestReview(t.Context(), "group/repo", model.MergeRequest{
+		IID: 1, Username: author, CreatedAt: createdAt, MergedAt: mergedAt,
+	})
+	if err != nil {
+		t.Fatalf("GetMergeRequestReview failed: %v", err)
+	}
+
+	want := []model.ReviewEvent{
+		{Username: reviewer, Kind: model.ReviewEventApproval, CreatedAt: approvedAt},
+		{Username: "silent", Kind: model.ReviewEventApproval, CreatedAt: mergedAt, Estimated: true},
+	}
+	if len(review.Events) != len(want) {
+		t.Fatalf("Events = %+v, want %+v", review.Events, want)
+	}
+	for i := range want {
+		if got := review.Events[i]; got.Username != want[i].Username || got.Kind != want[i].Kind ||
+			!got.CreatedAt.Equal(want[i].CreatedAt) || got.Estimated != want[i].Estimated {
+			t.Errorf("Events[%d] = %+v, want %+v", i, got, want[i])
+		}
+	}
+}
@@ -0,0 +1,188 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package api
+
+import (
+	"context"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	defaultBaseDelay = time.Second
+	defaultMaxDelay  = time.Minute
+)
+
+// retryTransport retries GitLab requests failed because of network errors, rate limiting or server errors
+// with exponential backoff. It respects Retry-After and RateLimit-* headers and pauses all requests
+// when the rate limit is exhausted, so the next request doesn't fail as well.
+// Every attempt also waits for the limiter, so parallel syncs share the same request budget.
+type retryTransport struct {
+	next       http.RoundTripper
+	limiter    *rate.Limiter
+	maxRetries int
+	// attemptTimeout limits every attempt separately, so waiting between attempts isn't limited by it
+	attemptTimeout time.Duration
+	baseDelay      time.Duration
+	maxDelay       time.Duration
+
+	mu          sync.Mutex
+	pausedUntil time.Time
+}
+
+func newRetryTransport(
+	next http.RoundTripper, limiter *rate.Limiter, maxRetries int, attemptTimeout time.Duration,
+) *retryTransport {
+	return &retryTransport{
+		next:           next,
+		limiter:        limiter,
+		maxRetries:     maxRetries,
+		attemptTimeout: attemptTimeout,
+		baseDelay:      defaultBaseDelay,
+		maxDelay:       defaultMaxDelay,
+	}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if err := sleep(req.Context(), time.Until(t.pause())); err != nil {
+			return nil, err
+		}
+
+		if err := t.limiter.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+
+		resp, err := t.roundTripOnce(req)
+		if resp != nil {
+			t.pauseOnExhaustedLimit(resp.Header)
+		}
+
+		if !shouldRetry(resp, err) || attempt >= t.maxRetries {
+			return resp, err
+		}
+
+		delay := t.backoff(attempt)
+		if resp != nil {
+			if retryAfter, ok := retryAfterDelay(resp.Header); ok {
+				delay = retryAfter
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			log.Printf("GitLab returned %d for %s, retrying in %s", resp.StatusCode, req.URL.Path, delay)
+		} else {
+			log.Printf("GitLab request %s failed: %v, retrying in %s", req.URL.Path, err, delay)
+		}
+
+		if err := sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (t *retryTransport) roundTripOnce(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), t.attemptTimeout)
+
+	resp, err := t.next.RoundTrip(req.Clone(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	resp.Body = &cancelOnCloseBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+func (t *retryTransport) pause() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.pausedUntil
+}
+
+// pauseOnExhaustedLimit pauses requests till the rate limit is reset if no requests are left.
+func (t *retryTransport) pauseOnExhaustedLimit(header http.Header) {
+	if header.Get("RateLimit-Remaining") != "0" {
+		return
+	}
+
+	reset, err := strconv.ParseInt(header.Get("RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if resetAt := time.Unix(reset, 0); resetAt.After(t.pausedUntil) {
+		t.pausedUntil = resetAt
+	}
+}
+
+// backoff returns exponentially growing delay with a random jitter up to a half of it.
+func (t *retryTransport) backoff(attempt int) time.Duration {
+	delay := t.baseDelay << attempt
+	if delay <= 0 || delay > t.maxDelay {
+		delay = t.maxDelay
+	}
+	return delay/2 + rand.N(delay/2+1) //nolint:gosec // jitter doesn't have to be cryptographically secure
+}
+
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// retryAfterDelay parses Retry-After header which is either an amount of seconds or a date.
+func retryAfterDelay(header http.Header) (time.Duration, bool) {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date), true
+	}
+
+	return 0, false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// cancelOnCloseBody releases the context of an attempt when its response body is closed.
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
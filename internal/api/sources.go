@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"mr-metrics/internal/config"
+	"mr-metrics/internal/model"
+	"time"
+)
+
+// SourcesClient sends the requests of a project to the GitLab source it belongs to,
+// the source is taken from the qualified name of the project.
+type SourcesClient struct {
+	// Clients by source names, the default source has an empty name
+	clients map[string]*GitLabClient
+}
+
+func NewSourcesClient(cfg *config.Config) *SourcesClient {
+	clients := map[string]*GitLabClient{"": NewGitLabClient(cfg)}
+	for _, source := range cfg.Sources {
+		clients[source.Name] = newGitLabClient(source.URL, source.Token, cfg)
+	}
+	return &SourcesClient{clients: clients}
+}
+
+// GetMergedMRCounts returns merged MRs of a project that were updated since the given time
+// together with the ID of the project in its source.
+func (s *SourcesClient) GetMergedMRCounts(
+	ctx context.Context, projectName string, since time.Time,
+) ([]model.MergeRequest, int, error) {
+	client, path, err := s.route(projectName)
+	if err != nil {
+		return nil, 0, err
+	}
+	return client.GetMergedMRCounts(ctx, path, since)
+}
+
+// DiscoverProjects discovers projects by topics and groups in the source and returns their qualified names.
+func (s *SourcesClient) DiscoverProjects(ctx context.Context, source string, topics, groups []string) ([]string, error) {
+	client, ok := s.clients[source]
+	if !ok {
+		return nil, fmt.Errorf("source %s is not configured", source)
+	}
+
+	paths, err := client.DiscoverProjects(ctx, topics, groups)
+	if err != nil {
+		return nil, err
+	}
+
+	projectNames := make([]string, len(paths))
+	for i, path := range paths {
+		projectNames[i] = model.QualifiedProjectName(source, path)
+	}
+	return projectNames, nil
+}
+
+func (s *SourcesClient) GetMergeRequestReview(
+	ctx context.Context, projectName string, mr model.MergeRequest,
+) (model.MergeRequestReview, error) {
+	client, path, err := s.route(projectName)
+	if err != nil {
+		return model.MergeRequestReview{}, err
+	}
+	return client.GetMergeRequestReview(ctx, path, mr)
+}
+
+func (s *SourcesClient) GetMergeRequestChangesCount(ctx context.Context, projectName string, iid int) (int, error) {
+	client, path, err := s.route(projectName)
+	if err != nil {
+		return 0, err
+	}
+	return client.GetMergeRequestChangesCount(ctx, path, iid)
+}
+
+// route returns the client of the source of the project and the path of the project in it.
+func (s *SourcesClient) route(projectName string) (*GitLabClient, string, error) {
+	source, path := model.SplitProjectName(projectName)
+	client, ok := s.clients[source]
+	if !ok {
+		return nil, "", fmt.Errorf("source %s is not configured", source)
+	}
+	return client, path, nil
+}
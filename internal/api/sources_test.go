@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"mr-metrics/internal/api"
+	"mr-metrics/internal/config"
+)
+
+type project struct {
+	PathWithNamespace string `json:"path_with_namespace"`
+	Archived          bool   `json:"archived"`
+}
+
+// newProjectsServer serves the projects of a GitLab instance for any topic and group.
+func newProjectsServer(t *testing.T, projects ...project) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if err := json.NewEncoder(w).Encode(projects); err != nil {
+			t.Errorf("failed to write response: %v", err)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSourcesClientDiscoverProjects(t *testing.T) {
+	t.Parallel()
+
+	defaultSource := newProjectsServer(t, project{PathWithNamespace: "group/web"})
+	oss := newProjectsServer(t,
+		project{PathWithNamespace: "org/library"},
+		project{PathWithNamespace: "org/old", Archived: true},
+	)
+	client := api.NewSourcesClient(&config.Config{
+		GitLabHostURL: defaultSource.URL,
+		GitLabToken:   "token",
+		Sources:       []config.SourceConfig{{Name: "oss", URL: oss.URL, Token: "token"}},
+	})
+
+	tests := []struct {
+		source string
+		want   []string
+	}{
+		{source: "", want: []string{"group/web"}},
+		{source: "oss", want: []string{"oss:org/library"}},
+	}
+	for _, tt := range tests {
+		got, err := client.DiscoverProjects(t.Context(), tt.source, []string{"metrics"}, []string{"org"})
+		if err != nil {
+			t.Fatalf("DiscoverProjects of %q failed: %v", tt.source, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("DiscoverProjects of %q = %v, want %v", tt.source, got, tt.want)
+		}
+	}
+
+	if _, err := client.DiscoverProjects(t.Context(), "unknown", []string{"metrics"}, nil); err == nil {
+		t.Error("DiscoverProjects of an unknown source succeeded")
+	}
+}
@@ -8,69 +8,392 @@ import (
 	"cmp"
 	"fmt"
 	"github.com/joho/godotenv"
-	"log"
+	"mr-metrics/internal/model"
 	"net/url"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 )
 
+const dateLayout = "2006-01-02"
+
 type Config struct {
 	Port          string
 	GitLabToken   string
 	GitLabHostURL string
 	ProjectNames  []string
-	DatabaseURL   string
-	CacheTTL      time.Duration
+	// Topics and Groups are resolved into projects on every update cycle
+	Topics           []string
+	Groups           []string
+	DatabaseURL      string
+	CacheTTL         time.Duration
+	GitLabMaxRetries int
+	// Limit of time a single project is synced for
+	SyncProjectTimeout time.Duration
+	// Time active HTTP requests are waited for on shutdown
+	ShutdownTimeout time.Duration
+	// Amount of projects synced in parallel
+	SyncConcurrency int
+	// Requests per second sent to GitLab by all syncs together, 0 means unlimited
+	GitLabRateLimit float64
+	// Token required to request a sync via API, the API is disabled if it is empty
+	APIToken string
+	// How often recently merged requests are fetched again to remove deleted ones, 0 disables it
+	ReconcileInterval time.Duration
+	// How far back recently merged requests are fetched again
+	ReconcileWindow time.Duration
+	// Whether merge requests which titles start with "Revert" are excluded from the counts
+	ExcludeReverts bool
+	// Whether migrations are applied on start, otherwise they are applied by the migrate command
+	AutoMigrate bool
+
+	// Path of the configuration file, empty if there is none
+	ConfigFile string
+	// GitLab instances besides the default one given by GitLabHostURL and GitLabToken
+	Sources []SourceConfig
+	// Settings of the projects listed in the configuration file by their qualified names
+	Projects map[string]ProjectConfig
+	// Users whose merge requests and reviews are not stored, e.g. bots
+	ExcludedUsers []string
+	// Usernames of team members by team names
+	Teams map[string][]string
+	// Names shown instead of usernames
+	Aliases map[string]string
+}
+
+// SourceConfig is a GitLab instance projects are synced from.
+type SourceConfig struct {
+	Name  string
+	URL   string
+	Token string
+	// Topics and Groups are resolved into projects of the source on every update cycle
+	Topics []string
+	Groups []string
+}
+
+// ProjectConfig is the settings of a single project.
+type ProjectConfig struct {
+	// Name of the GitLab source the project belongs to, empty for the default one
+	Source string
+	// How often the project is synced
+	SyncInterval time.Duration
+	// Merge requests merged before this date are not stored, zero to store the whole history
+	StartDate time.Time
+}
+
+// Load loads the configuration from the environment and the configuration file
+// given by the path or by CONFIG_FILE, the environment overrides the file.
+func Load(configFile string) (*Config, error) {
+	return load(configFile, true, true)
+}
+
+// LoadDemo loads the configuration of the demo mode, which doesn't sync GitLab and keeps data in memory,
+// so GitLab and database settings aren't required.
+func LoadDemo(configFile string) (*Config, error) {
+	return load(configFile, false, false)
+}
+
+// LoadDatabase loads the configuration of commands that only use the database, so GitLab settings aren't required.
+func LoadDatabase(configFile string) (*Config, error) {
+	return load(configFile, false, true)
+}
+
+// Project returns the settings of a project, the projects missing in the configuration file get the defaults.
+func (c *Config) Project(name string) ProjectConfig {
+	project := c.Projects[name]
+	project.SyncInterval = cmp.Or(project.SyncInterval, c.CacheTTL)
+	return project
+}
+
+// SyncTick returns how often projects are checked for being due for a sync, it is the shortest sync interval.
+func (c *Config) SyncTick() time.Duration {
+	tick := c.CacheTTL
+	for _, project := range c.Projects {
+		if project.SyncInterval > 0 && project.SyncInterval < tick {
+			tick = project.SyncInterval
+		}
+	}
+	return tick
 }
 
-func Load() (*Config, error) {
-	var errors []string
+// IsExcluded reports whether merge requests and reviews of the user are not stored.
+func (c *Config) IsExcluded(username string) bool {
+	return slices.Contains(c.ExcludedUsers, username)
+}
 
+// settingNames returns the names of the environment variables, the same settings can be set in the file.
+func settingNames() []string {
+	return []string{
+		"PORT", "GITLAB_TOKEN", "GITLAB_HOST_URL", "GITLAB_PROJECT_NAMES", "GITLAB_TOPICS", "GITLAB_GROUPS",
+		"DATABASE_URL", "CACHE_TTL", "GITLAB_MAX_RETRIES", "SYNC_PROJECT_TIMEOUT", "SHUTDOWN_TIMEOUT",
+		"SYNC_CONCURRENCY", "GITLAB_RATE_LIMIT", "API_TOKEN", "RECONCILE_INTERVAL", "RECONCILE_WINDOW",
+		"EXCLUDE_REVERTS", "AUTO_MIGRATE",
+	}
+}
+
+// loader reads settings from the environment falling back to the file and collects validation errors,
+// so all of them are reported at once.
+type loader struct {
+	file   *fileConfig
+	errors []string
+}
+
+func load(configFile string, requireGitLab, requireDatabase bool) (*Config, error) {
 	if err := godotenv.Load(); err != nil && !os.IsNotExist(err) {
 		return nil, fmt.Errorf("error loading .env file: %w", err)
 	}
 
-	gitlabToken := os.Getenv("GITLAB_TOKEN")
-	if gitlabToken == "" {
-		errors = append(errors, "GITLAB_TOKEN is required")
+	l := &loader{}
+	configFile = cmp.Or(configFile, os.Getenv("CONFIG_FILE"))
+	if configFile != "" {
+		file, err := readFile(configFile)
+		if err != nil {
+			return nil, err
+		}
+		l.file = file
+
+		for _, key := range file.unknownSettings() {
+			if lower := strings.ToLower(key); lower != key && slices.Contains(settingNames(), strings.ToUpper(key)) {
+				l.errorf("unknown setting %s in %s, use %s", key, configFile, lower)
+				continue
+			}
+			l.errorf("unknown setting %s in %s", key, configFile)
+		}
 	}
 
-	databaseURL := os.Getenv("DATABASE_URL")
-	if databaseURL == "" {
-		errors = append(errors, "DATABASE_URL is required")
+	sourceURL, sourceToken, sources := l.sources(requireGitLab)
+
+	gitlabToken := cmp.Or(l.get("GITLAB_TOKEN"), sourceToken)
+	if gitlabToken == "" && requireGitLab {
+		l.errorf("GITLAB_TOKEN is required")
 	}
 
-	gitlabHostURL := cmp.Or(os.Getenv("GITLAB_HOST_URL"), "https://gitlab.com")
+	databaseURL := l.get("DATABASE_URL")
+	if databaseURL == "" && requireDatabase {
+		l.errorf("DATABASE_URL is required")
+	}
+
+	gitlabHostURL := cmp.Or(l.get("GITLAB_HOST_URL"), sourceURL, "https://gitlab.com")
 	if _, err := url.Parse(gitlabHostURL); err != nil {
-		errors = append(errors, fmt.Sprintf("invalid GITLAB_HOST_URL: %v", err))
+		l.errorf("invalid GITLAB_HOST_URL: %v", err)
+	}
+
+	cacheTTL := l.duration("CACHE_TTL", "1h")
+	projects, fileProjectNames := l.projects(sources)
+
+	projectNames := splitList(l.get("GITLAB_PROJECT_NAMES"))
+	for _, projectName := range projectNames {
+		source, _ := model.SplitProjectName(projectName)
+		if source != "" && !slices.ContainsFunc(sources, func(s SourceConfig) bool { return s.Name == source }) {
+			l.errorf("GITLAB_PROJECT_NAMES: source %s of %s is not configured", source, projectName)
+		}
+	}
+	if os.Getenv("GITLAB_PROJECT_NAMES") == "" {
+		// Projects configured in the file are synced unless the environment overrides the list
+		for _, projectName := range fileProjectNames {
+			if !slices.Contains(projectNames, projectName) {
+				projectNames = append(projectNames, projectName)
+			}
+		}
 	}
 
-	projectNames := strings.Split(os.Getenv("GITLAB_PROJECT_NAMES"), ",")
-	if len(projectNames) == 0 {
-		errors = append(errors, "GITLAB_PROJECT_NAMES is required")
+	topics := splitList(l.get("GITLAB_TOPICS"))
+	groups := splitList(l.get("GITLAB_GROUPS"))
+	discovers := len(topics) > 0 || len(groups) > 0 ||
+		slices.ContainsFunc(sources, func(s SourceConfig) bool { return len(s.Topics) > 0 || len(s.Groups) > 0 })
+	if len(projectNames) == 0 && !discovers && requireGitLab {
+		l.errorf("one of GITLAB_PROJECT_NAMES, GITLAB_TOPICS or GITLAB_GROUPS is required")
 	}
 
-	cacheTTL := parseDuration(cmp.Or(os.Getenv("CACHE_TTL"), "1h"))
+	cfg := &Config{
+		Port:               cmp.Or(l.get("PORT"), "8080"),
+		GitLabToken:        gitlabToken,
+		GitLabHostURL:      gitlabHostURL,
+		ProjectNames:       projectNames,
+		Topics:             topics,
+		Groups:             groups,
+		DatabaseURL:        databaseURL,
+		CacheTTL:           cacheTTL,
+		GitLabMaxRetries:   l.integer("GITLAB_MAX_RETRIES", "5", 0),
+		SyncProjectTimeout: l.duration("SYNC_PROJECT_TIMEOUT", "30m"),
+		ShutdownTimeout:    l.duration("SHUTDOWN_TIMEOUT", "15s"),
+		SyncConcurrency:    l.integer("SYNC_CONCURRENCY", "4", 1),
+		GitLabRateLimit:    l.rate("GITLAB_RATE_LIMIT"),
+		APIToken:           l.get("API_TOKEN"),
+		ReconcileInterval:  l.duration("RECONCILE_INTERVAL", "24h"),
+		ReconcileWindow:    l.duration("RECONCILE_WINDOW", "720h"),
+		ExcludeReverts:     l.boolean("EXCLUDE_REVERTS", "false"),
+		AutoMigrate:        l.boolean("AUTO_MIGRATE", "true"),
+		ConfigFile:         configFile,
+		Sources:            sources,
+		Projects:           projects,
+	}
+	if l.file != nil {
+		cfg.ExcludedUsers = l.file.ExcludedUsers
+		cfg.Teams = l.file.Teams
+		cfg.Aliases = l.file.Aliases
+	}
 
-	if len(errors) > 0 {
-		return nil, fmt.Errorf("configuration errors:\n- %s", strings.Join(errors, "\n- "))
+	if len(l.errors) > 0 {
+		return nil, fmt.Errorf("configuration errors:\n- %s", strings.Join(l.errors, "\n- "))
 	}
 
-	return &Config{
-		Port:          cmp.Or(os.Getenv("PORT"), "8080"),
-		GitLabToken:   gitlabToken,
-		GitLabHostURL: gitlabHostURL,
-		ProjectNames:  projectNames,
-		DatabaseURL:   databaseURL,
-		CacheTTL:      cacheTTL,
-	}, nil
+	return cfg, nil
+}
+
+func (l *loader) errorf(format string, args ...any) {
+	l.errors = append(l.errors, fmt.Sprintf(format, args...))
+}
+
+// get returns the value of the environment variable or of the same setting in the file.
+func (l *loader) get(name string) string {
+	return cmp.Or(os.Getenv(name), l.file.setting(name))
 }
 
-func parseDuration(value string) time.Duration {
+func (l *loader) duration(name, defaultValue string) time.Duration {
+	value := cmp.Or(l.get(name), defaultValue)
 	d, err := time.ParseDuration(value)
 	if err != nil {
-		log.Fatalf("Invalid duration format: %s", value)
+		l.errorf("%s has invalid duration format: %s", name, value)
 	}
 	return d
 }
+
+func (l *loader) integer(name, defaultValue string, minValue int) int {
+	n, err := strconv.Atoi(cmp.Or(l.get(name), defaultValue))
+	if err != nil || n < minValue {
+		if minValue > 0 {
+			l.errorf("%s must be a positive number", name)
+		} else {
+			l.errorf("%s must be a non-negative number", name)
+		}
+	}
+	return n
+}
+
+func (l *loader) rate(name string) float64 {
+	rate, err := strconv.ParseFloat(cmp.Or(l.get(name), "0"), 64)
+	if err != nil || rate < 0 {
+		l.errorf("%s must be a non-negative number", name)
+	}
+	return rate
+}
+
+func (l *loader) boolean(name, defaultValue string) bool {
+	b, err := strconv.ParseBool(cmp.Or(l.get(name), defaultValue))
+	if err != nil {
+		l.errorf("%s must be a boolean", name)
+	}
+	return b
+}
+
+// sources validates the GitLab sources configured in the file. The first one is the default source,
+// its URL and token are the defaults of GITLAB_HOST_URL and GITLAB_TOKEN. The rest are returned as additional ones.
+func (l *loader) sources(requireGitLab bool) (string, string, []SourceConfig) {
+	if l.file == nil || len(l.file.Sources) == 0 {
+		return "", "", nil
+	}
+
+	var sources []SourceConfig
+	for i, source := range l.file.Sources {
+		switch {
+		case source.Name == "":
+			l.errorf("sources: name is required")
+		case strings.Contains(source.Name, ":"):
+			l.errorf("sources: name of %s must not contain \":\"", source.Name)
+		case slices.ContainsFunc(l.file.Sources[:i], func(s fileSource) bool { return s.Name == source.Name }):
+			l.errorf("sources: %s is listed twice", source.Name)
+		}
+
+		if source.URL == "" {
+			l.errorf("sources: url of %s is required", source.Name)
+		} else if _, err := url.Parse(source.URL); err != nil {
+			l.errorf("sources: invalid url of %s: %v", source.Name, err)
+		}
+
+		// The token of the default source may be given by GITLAB_TOKEN
+		if i > 0 {
+			if source.Token == "" && requireGitLab {
+				l.errorf("sources: token of %s is required", source.Name)
+			}
+			sources = append(sources, SourceConfig{
+				Name:   source.Name,
+				URL:    source.URL,
+				Token:  source.Token,
+				Topics: source.Topics,
+				Groups: source.Groups,
+			})
+		} else if len(source.Topics) > 0 || len(source.Groups) > 0 {
+			l.errorf("sources: topics and groups of the default source %s are set by gitlab_topics and gitlab_groups",
+				source.Name)
+		}
+	}
+
+	return l.file.Sources[0].URL, l.file.Sources[0].Token, sources
+}
+
+// projects validates the settings of the projects listed in the file.
+// It returns them by their qualified names together with the names in the order of the file.
+func (l *loader) projects(sources []SourceConfig) (map[string]ProjectConfig, []string) {
+	if l.file == nil {
+		return nil, nil
+	}
+
+	projects := make(map[string]ProjectConfig)
+	var projectNames []string
+	for _, project := range l.file.Projects {
+		if project.Name == "" {
+			l.errorf("projects: name is required")
+			continue
+		}
+
+		// Projects of the default source keep their plain names
+		source := project.Source
+		if len(l.file.Sources) > 0 && source == l.file.Sources[0].Name {
+			source = ""
+		}
+		if source != "" && !slices.ContainsFunc(sources, func(s SourceConfig) bool { return s.Name == source }) {
+			l.errorf("projects: source %s of %s is not configured", project.Source, project.Name)
+		}
+
+		name := model.QualifiedProjectName(source, project.Name)
+		if _, exists := projects[name]; exists {
+			l.errorf("projects: %s is listed twice", name)
+			continue
+		}
+
+		settings := ProjectConfig{Source: source}
+		if project.SyncInterval != "" {
+			interval, err := time.ParseDuration(project.SyncInterval)
+			if err != nil || interval <= 0 {
+				l.errorf("projects: sync_interval of %s must be a positive duration", name)
+			}
+			settings.SyncInterval = interval
+		}
+
+		if project.StartDate != "" {
+			startDate, err := time.Parse(dateLayout, project.StartDate)
+			if err != nil {
+				l.errorf("projects: start_date of %s must be a date in YYYY-MM-DD format", name)
+			}
+			settings.StartDate = startDate
+		}
+
+		projects[name] = settings
+		projectNames = append(projectNames, name)
+	}
+	return projects, projectNames
+}
+
+// splitList splits a comma-separated value, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// fileConfig is the configuration file. Every environment variable can be set in the file as well
+// under its name in lower case, e.g. cache_ttl, the environment variables override the file.
+type fileConfig struct {
+	Sources       []fileSource        `toml:"sources"        yaml:"sources"`
+	Projects      []fileProject       `toml:"projects"       yaml:"projects"`
+	ExcludedUsers []string            `toml:"excluded_users" yaml:"excluded_users"`
+	Teams         map[string][]string `toml:"teams"          yaml:"teams"`
+	Aliases       map[string]string   `toml:"aliases"        yaml:"aliases"`
+
+	// settings are the values of environment variables by their names in lower case
+	settings map[string]any
+}
+
+type fileSource struct {
+	Name   string   `toml:"name"   yaml:"name"`
+	URL    string   `toml:"url"    yaml:"url"`
+	Token  string   `toml:"token"  yaml:"token"`
+	Topics []string `toml:"topics" yaml:"topics"`
+	Groups []string `toml:"groups" yaml:"groups"`
+}
+
+type fileProject struct {
+	Name   string `toml:"name"   yaml:"name"`
+	Source string `toml:"source" yaml:"source"`
+	// Durations and dates are strings, so they are parsed the same way as environment variables
+	SyncInterval string `toml:"sync_interval" yaml:"sync_interval"`
+	StartDate    string `toml:"start_date"    yaml:"start_date"`
+}
+
+// readFile reads a YAML or TOML configuration file chosen by its extension.
+func readFile(path string) (*fileConfig, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var unmarshal func(content []byte, v any) error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		unmarshal = yaml.Unmarshal
+	case ".toml":
+		unmarshal = toml.Unmarshal
+	default:
+		return nil, fmt.Errorf("unsupported config file %s, use .yaml, .yml or .toml", path)
+	}
+
+	// NOTE(danilax86): The file is decoded twice: the sections into the structure
+	// and the settings into a map, so every environment variable doesn't need a field.
+	var file fileConfig
+	if err := unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	if err := unmarshal(content, &file.settings); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	for _, section := range []string{"sources", "projects", "excluded_users", "teams", "aliases"} {
+		delete(file.settings, section)
+	}
+
+	return &file, nil
+}
+
+// unknownSettings returns the keys of the file which are neither sections nor settings in ascending order.
+// Settings are named in lower case only, e.g. GITLAB_TOKEN is an unknown key.
+func (f *fileConfig) unknownSettings() []string {
+	var keys []string
+	for key := range f.settings {
+		if key != strings.ToLower(key) || !slices.Contains(settingNames(), strings.ToUpper(key)) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// setting returns the value of the setting from the file formatted as an environment variable,
+// lists are joined with commas.
+func (f *fileConfig) setting(name string) string {
+	if f == nil {
+		return ""
+	}
+
+	switch value := f.settings[strings.ToLower(name)].(type) {
+	case nil:
+		return ""
+	case []any:
+		items := make([]string, 0, len(value))
+		for _, item := range value {
+			items = append(items, fmt.Sprint(item))
+		}
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(value)
+	}
+}
@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"mr-metrics/internal/config"
+)
+
+func TestLoadFileSettingNames(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "lower case", content: "cache_ttl: 10m\n"},
+		{name: "upper case", content: "CACHE_TTL: 10m\n", wantErr: "unknown setting CACHE_TTL in %s, use cache_ttl"},
+		{name: "mixed case", content: "Cache_TTL: 10m\n", wantErr: "unknown setting Cache_TTL in %s, use cache_ttl"},
+		{name: "unknown", content: "cache: 10m\n", wantErr: "unknown setting cache in %s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			configFile := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(configFile, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("failed to write config file: %v", err)
+			}
+
+			cfg, err := config.LoadDemo(configFile)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if cfg.CacheTTL != 10*time.Minute {
+					t.Errorf("CacheTTL = %v, want 10m", cfg.CacheTTL)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), strings.ReplaceAll(tt.wantErr, "%s", configFile)) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadFileSourceDiscovery(t *testing.T) {
+	t.Parallel()
+
+	writeConfig := func(t *testing.T, content string) string {
+		t.Helper()
+		configFile := filepath.Join(t.TempDir(), "config.yaml")
+		if err := os.WriteFile(configFile, []byte(content), 0o600); err != nil {
+			t.Fatalf("failed to write config file: %v", err)
+		}
+		return configFile
+	}
+
+	cfg, err := config.LoadDemo(writeConfig(t, `
+sources:
+  - name: internal
+    url: https://gitlab.example.com
+  - name: oss
+    url: https://gitlab.com
+    topics: [metrics]
+    groups: [org]
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Sources) != 1 || !slices.Equal(cfg.Sources[0].Topics, []string{"metrics"}) ||
+		!slices.Equal(cfg.Sources[0].Groups, []string{"org"}) {
+		t.Errorf("Sources = %+v, want oss with its topics and groups", cfg.Sources)
+	}
+
+	// The default source is searched by gitlab_topics and gitlab_groups
+	_, err = config.LoadDemo(writeConfig(t, `
+sources:
+  - name: internal
+    url: https://gitlab.example.com
+    topics: [metrics]
+`))
+	if want := "topics and groups of the default source internal are set by gitlab_topics and gitlab_groups"; err == nil ||
+		!strings.Contains(err.Error(), want) {
+		t.Errorf("error = %v, want %q", err, want)
+	}
+}
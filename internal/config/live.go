@@ -0,0 +1,213 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDelay is the time the configuration file is reloaded after its last change,
+// so a file written in several steps by an editor is read once.
+const reloadDelay = 200 * time.Millisecond
+
+// Live is the configuration replaced on SIGHUP and when the configuration file changes,
+// so projects, sync intervals and display settings are applied without a restart.
+type Live struct {
+	current atomic.Pointer[Config]
+	// load is nil if the configuration is never reloaded
+	load func(configFile string) (*Config, error)
+
+	mu          sync.Mutex
+	subscribers []chan struct{}
+}
+
+// NewLive returns the configuration reloaded by the same function it was loaded by, e.g. Load.
+func NewLive(cfg *Config, load func(configFile string) (*Config, error)) *Live {
+	l := &Live{load: load}
+	l.current.Store(cfg)
+	return l
+}
+
+// Static returns the configuration which is never reloaded, e.g. for one-shot commands.
+func Static(cfg *Config) *Live {
+	return NewLive(cfg, nil)
+}
+
+// Get returns the current configuration, it must not be modified.
+func (l *Live) Get() *Config {
+	return l.current.Load()
+}
+
+// Subscribe returns a channel receiving a value after the configuration is reloaded.
+// Several reloads in a row may be delivered as a single value.
+func (l *Live) Subscribe() <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ch := make(chan struct{}, 1)
+	l.subscribers = append(l.subscribers, ch)
+	return ch
+}
+
+// Watch reloads the configuration on SIGHUP and when the configuration file changes till the context is done.
+// An invalid configuration is logged and the previous one is kept. If the file can't be watched,
+// the failure is logged and the configuration is reloaded only on SIGHUP.
+func (l *Live) Watch(ctx context.Context) {
+	if l.load == nil {
+		return
+	}
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	var (
+		events <-chan fsnotify.Event
+		errs   <-chan error
+	)
+	if configFile := l.Get().ConfigFile; configFile != "" {
+		watcher, err := watchDir(filepath.Dir(configFile))
+		if err != nil {
+			// SIGHUP still reloads the configuration
+			log.Printf("Failed to watch config file, reload it with SIGHUP: %v", err)
+		} else {
+			defer watcher.Close()
+			events, errs = watcher.Events, watcher.Errors
+		}
+	}
+
+	reload := time.NewTimer(reloadDelay)
+	reload.Stop()
+	defer reload.Stop()
+
+	for {
+		select {
+		case <-hangup:
+			log.Printf("Reloading config on SIGHUP")
+			l.reload()
+		case event := <-events:
+			if l.isConfigFile(event.Name) {
+				reload.Reset(reloadDelay)
+			}
+		case <-reload.C:
+			log.Printf("Reloading changed config file")
+			l.reload()
+		case err := <-errs:
+			log.Printf("Failed to watch config file: %v", err)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// watchDir returns a watcher of the directory of the configuration file.
+// NOTE(danilax86): The directory is watched rather than the file,
+// editors and Kubernetes replace the file instead of writing into it.
+func watchDir(dir string) (*fsnotify.Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create watcher: %w", err)
+	}
+
+	if err := watcher.Add(dir); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+	return watcher, nil
+}
+
+func (l *Live) isConfigFile(name string) bool {
+	configFile := l.Get().ConfigFile
+	// Kubernetes replaces the ..data symlink pointing to the directory with the file
+	return filepath.Clean(name) == filepath.Clean(configFile) || filepath.Base(name) == "..data"
+}
+
+func (l *Live) reload() {
+	current := l.Get()
+
+	cfg, err := l.load(current.ConfigFile)
+	if err != nil {
+		log.Printf("Failed to reload config, keeping the previous one: %v", err)
+		return
+	}
+
+	keepRestartOnly(current, cfg)
+	l.current.Store(cfg)
+	log.Printf("Config reloaded")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, ch := range l.subscribers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// keepRestartOnly copies the settings which are used only on start from the current configuration
+// to the reloaded one and logs the changed ones, so it's clear they are not applied.
+func keepRestartOnly(current, reloaded *Config) {
+	changed := func(name string, changed bool) {
+		if changed {
+			log.Printf("%s changed, restart to apply it", name)
+		}
+	}
+
+	changed("PORT", current.Port != reloaded.Port)
+	changed("DATABASE_URL", current.DatabaseURL != reloaded.DatabaseURL)
+	changed("GITLAB_HOST_URL", current.GitLabHostURL != reloaded.GitLabHostURL)
+	changed("GITLAB_TOKEN", current.GitLabToken != reloaded.GitLabToken)
+	changed("GITLAB_MAX_RETRIES", current.GitLabMaxRetries != reloaded.GitLabMaxRetries)
+	changed("GITLAB_RATE_LIMIT", current.GitLabRateLimit != reloaded.GitLabRateLimit)
+	changed("sources", !slices.EqualFunc(current.Sources, reloaded.Sources, sameConnection))
+	changed("SHUTDOWN_TIMEOUT", current.ShutdownTimeout != reloaded.ShutdownTimeout)
+	changed("RECONCILE_INTERVAL", current.ReconcileInterval != reloaded.ReconcileInterval)
+	changed("EXCLUDE_REVERTS", current.ExcludeReverts != reloaded.ExcludeReverts)
+	changed("AUTO_MIGRATE", current.AutoMigrate != reloaded.AutoMigrate)
+
+	reloaded.Port = current.Port
+	reloaded.DatabaseURL = current.DatabaseURL
+	reloaded.GitLabHostURL = current.GitLabHostURL
+	reloaded.GitLabToken = current.GitLabToken
+	reloaded.GitLabMaxRetries = current.GitLabMaxRetries
+	reloaded.GitLabRateLimit = current.GitLabRateLimit
+	reloaded.Sources = keepSourceConnections(current.Sources, reloaded.Sources)
+	reloaded.ShutdownTimeout = current.ShutdownTimeout
+	reloaded.ReconcileInterval = current.ReconcileInterval
+	reloaded.ExcludeReverts = current.ExcludeReverts
+	reloaded.AutoMigrate = current.AutoMigrate
+}
+
+func sameConnection(a, b SourceConfig) bool {
+	return a.Name == b.Name && a.URL == b.URL && a.Token == b.Token
+}
+
+// keepSourceConnections returns the current sources with the topics and groups of the reloaded ones,
+// since they are resolved on every update, while the clients of the sources are created only on start.
+func keepSourceConnections(current, reloaded []SourceConfig) []SourceConfig {
+	sources := make([]SourceConfig, len(current))
+	for i, source := range current {
+		sources[i] = SourceConfig{Name: source.Name, URL: source.URL, Token: source.Token}
+		j := slices.IndexFunc(reloaded, func(s SourceConfig) bool { return s.Name == source.Name })
+		if j >= 0 {
+			sources[i].Topics = reloaded[j].Topics
+			sources[i].Groups = reloaded[j].Groups
+		}
+	}
+	return sources
+}
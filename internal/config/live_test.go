@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package config_test
+
+import (
+	"context"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"mr-metrics/internal/config"
+)
+
+func TestWatchReloadsOnSIGHUPWithoutWatchedFile(t *testing.T) {
+	t.Parallel()
+
+	// The directory doesn't exist, so it can't be watched
+	configFile := filepath.Join(t.TempDir(), "missing", "config.yaml")
+	reloaded := make(chan string, 1)
+	live := config.NewLive(&config.Config{ConfigFile: configFile}, func(configFile string) (*config.Config, error) {
+		reloaded <- configFile
+		return &config.Config{ConfigFile: configFile}, nil
+	})
+
+	signal.Ignore(syscall.SIGHUP)
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		live.Watch(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// SIGHUP is sent till it's handled, so it's ignored till Watch installs its handler
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case got := <-reloaded:
+			if got != configFile {
+				t.Fatalf("reloaded %q, want %q", got, configFile)
+			}
+			return
+		case <-ticker.C:
+			if err := syscall.Kill(syscall.Getpid(), syscall.SIGHUP); err != nil {
+				t.Fatalf("failed to send SIGHUP: %v", err)
+			}
+		case <-timeout:
+			t.Fatal("config was not reloaded on SIGHUP")
+		}
+	}
+}
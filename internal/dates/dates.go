@@ -0,0 +1,35 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+// Package dates parses the dates of the stats periods given by the web UI and the commands
+// and turns them into the bounds of the periods.
+package dates
+
+import (
+	"fmt"
+	"mr-metrics/internal/consts"
+	"time"
+)
+
+// Layout is the format of the dates, e.g. 2025-01-31.
+const Layout = time.DateOnly
+
+// Parse parses a date in the Layout format.
+func Parse(value string) (time.Time, error) {
+	date, err := time.Parse(Layout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, use YYYY-MM-DD", value)
+	}
+	return date, nil
+}
+
+// StartOfDay returns the first moment of the day in UTC.
+func StartOfDay(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location()).UTC()
+}
+
+// EndOfDay returns the last moment of the day in UTC, merges made during the day are counted.
+func EndOfDay(date time.Time) time.Time {
+	return StartOfDay(date).Add(consts.OneDay - time.Nanosecond)
+}
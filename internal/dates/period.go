@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package dates
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrFromRequired is returned when only the end of a range is given.
+	ErrFromRequired = errors.New("the start of the range is required when its end is given")
+	// ErrFromAfterTo is returned when a range ends before it starts.
+	ErrFromAfterTo = errors.New("the start of the range must not be after its end")
+)
+
+// Period is the period the stats table is shown for: up to a date or inside a date range.
+type Period struct {
+	IsRange bool
+	// DateProvided is false when the stats are shown up to the current date
+	DateProvided bool
+	From         time.Time
+	To           time.Time
+}
+
+// ParsePeriod selects the period the same way for the web UI and the commands:
+// from and to for the merges made inside the range (to is the current date if it's empty),
+// date for the stats up to the date, and the stats up to the current date if all of them are empty.
+func ParsePeriod(date, from, to string) (Period, error) {
+	if from != "" || to != "" {
+		if from == "" {
+			return Period{}, ErrFromRequired
+		}
+
+		fromDate, toDate, err := ParseRange(from, to, time.Time{})
+		if err != nil {
+			return Period{}, err
+		}
+		return Period{IsRange: true, DateProvided: true, From: fromDate, To: toDate}, nil
+	}
+
+	if date == "" {
+		return Period{To: time.Now()}, nil
+	}
+
+	targetDate, err := Parse(date)
+	if err != nil {
+		return Period{}, err
+	}
+	return Period{DateProvided: true, To: targetDate}, nil
+}
+
+// ParseRange parses the bounds of a date range. Empty to stands for the current date
+// and empty from stands for defaultFrom.
+func ParseRange(from, to string, defaultFrom time.Time) (time.Time, time.Time, error) {
+	fromDate, toDate := defaultFrom, time.Now()
+
+	var err error
+	if from != "" {
+		if fromDate, err = Parse(from); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+
+	if to != "" {
+		if toDate, err = Parse(to); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+
+	if toDate.Before(fromDate) {
+		return time.Time{}, time.Time{}, ErrFromAfterTo
+	}
+
+	return fromDate, toDate, nil
+}
+
+// Labels returns the date the stats are shown up to or the bounds of the range.
+func (p Period) Labels() (string, string, string) {
+	switch {
+	case p.IsRange:
+		return "", p.From.Format(Layout), p.To.Format(Layout)
+	case p.DateProvided:
+		return p.To.Format(Layout), "", ""
+	default:
+		return "", "", ""
+	}
+}
@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package dates_test
+
+import (
+	"errors"
+	"testing"
+
+	"mr-metrics/internal/dates"
+)
+
+func TestParsePeriod(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name                       string
+		date, from, to             string
+		wantDate, wantFrom, wantTo string
+		wantErr                    error
+	}{
+		{name: "today"},
+		{name: "date", date: "2025-01-31", wantDate: "2025-01-31"},
+		{name: "range", from: "2025-01-01", to: "2025-01-31", wantFrom: "2025-01-01", wantTo: "2025-01-31"},
+		{name: "range overrides date", date: "2024-12-31", from: "2025-01-01", to: "2025-01-31", wantFrom: "2025-01-01", wantTo: "2025-01-31"},
+		{name: "only to", to: "2025-01-31", wantErr: dates.ErrFromRequired},
+		{name: "reversed range", from: "2025-02-01", to: "2025-01-31", wantErr: dates.ErrFromAfterTo},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			period, err := dates.ParsePeriod(tt.date, tt.from, tt.to)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParsePeriod returned %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if date, from, to := period.Labels(); date != tt.wantDate || from != tt.wantFrom || to != tt.wantTo {
+				t.Errorf("Labels = %q, %q, %q, want %q, %q, %q", date, from, to, tt.wantDate, tt.wantFrom, tt.wantTo)
+			}
+		})
+	}
+
+	if _, err := dates.ParsePeriod("31.01.2025", "", ""); err == nil {
+		t.Error("ParsePeriod of an invalid date succeeded")
+	}
+}
@@ -0,0 +1,58 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package db
+
+import (
+	"mr-metrics/internal/consts"
+	"mr-metrics/internal/model"
+	"time"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	// mrListLimit is the amount of merge requests shown in lists such as the largest ones.
+	mrListLimit = 5
+	daysInWeek  = 7
+)
+
+// mergeSample is a single merged request of a project.
+type mergeSample struct {
+	projectName string
+	username    string
+	mergedAt    time.Time
+}
+
+// buildWeeklyMerges splits merged requests into weeks between from and to.
+// Weeks without merged requests are kept, so gaps are visible.
+func buildWeeklyMerges(from, to time.Time, samples []mergeSample) model.WeeklyMerges {
+	weekly := model.WeeklyMerges{Counts: make(map[string]map[string]int)}
+
+	for week := weekStart(from); !week.After(to); week = week.Add(daysInWeek * consts.OneDay) {
+		key := week.Format(dateLayout)
+		weekly.Weeks = append(weekly.Weeks, key)
+		weekly.Counts[key] = make(map[string]int)
+	}
+
+	projectsSet := make(map[string]struct{})
+	for _, sample := range samples {
+		projectName := extractProjectName(sample.projectName)
+		projectsSet[projectName] = struct{}{}
+
+		key := weekStart(sample.mergedAt).Format(dateLayout)
+		if _, exists := weekly.Counts[key]; exists {
+			weekly.Counts[key][projectName]++
+		}
+	}
+
+	weekly.Projects = sortedKeys(projectsSet)
+	return weekly
+}
+
+// weekStart returns the beginning of the Monday of the week the time belongs to.
+func weekStart(t time.Time) time.Time {
+	t = t.UTC().Truncate(consts.OneDay)
+	daysSinceMonday := (int(t.Weekday()) + daysInWeek - 1) % daysInWeek
+	return t.Add(-time.Duration(daysSinceMonday) * consts.OneDay)
+}
@@ -0,0 +1,116 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	// leaderLockID is the key of the advisory lock held by the leader, it is "mrmetric" in ASCII.
+	leaderLockID int64 = 0x6d726d6574726963
+	// leaderRetryInterval is how often a replica tries to become the leader.
+	leaderRetryInterval = 15 * time.Second
+	// leaderCheckInterval is how often the leader checks that it still holds the lock.
+	leaderCheckInterval = 5 * time.Second
+)
+
+// Lead waits until this replica becomes the leader and runs the given function till the context is done.
+// The function context is cancelled when the leadership is lost, then the replica waits to become the leader again.
+//
+// NOTE(danilax86): The leader holds a session level advisory lock on a dedicated connection,
+// so the lock is released by Postgres as soon as the leader dies or loses the connection.
+func (p PostgresStore) Lead(ctx context.Context, run func(ctx context.Context)) error {
+	for {
+		led, err := p.TryLead(ctx, func(ctx context.Context) {
+			log.Printf("Acquired leadership")
+			run(ctx)
+		})
+		if err != nil {
+			log.Printf("Failed to acquire leadership: %v", err)
+		}
+		if led {
+			log.Printf("Released leadership")
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(leaderRetryInterval):
+		}
+	}
+}
+
+// TryLead runs the given function if no other replica is the leader and reports whether it did.
+// The function context is cancelled when the leadership is lost.
+func (p PostgresStore) TryLead(ctx context.Context, run func(ctx context.Context)) (bool, error) {
+	conn, err := p.tryLock(ctx)
+	if err != nil {
+		return false, err
+	}
+	if conn == nil {
+		return false, nil
+	}
+
+	p.leadWhileLocked(ctx, conn, run)
+	return true, nil
+}
+
+// tryLock returns the connection holding the leader lock or nil if another replica holds it.
+func (p PostgresStore) tryLock(ctx context.Context) (*sql.Conn, error) {
+	conn, err := p.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, leaderLockID).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	if !locked {
+		conn.Close()
+		return nil, nil //nolint:nilnil // another replica is the leader
+	}
+	return conn, nil
+}
+
+// leadWhileLocked runs the function while the connection holding the lock is alive and then releases the lock.
+func (p PostgresStore) leadWhileLocked(ctx context.Context, conn *sql.Conn, run func(ctx context.Context)) {
+	leaderCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		defer cancel()
+		run(leaderCtx)
+	})
+
+	ticker := time.NewTicker(leaderCheckInterval)
+	defer ticker.Stop()
+
+	for leaderCtx.Err() == nil {
+		select {
+		case <-leaderCtx.Done():
+		case <-ticker.C:
+			if _, err := conn.ExecContext(leaderCtx, `SELECT 1`); err != nil && leaderCtx.Err() == nil {
+				log.Printf("Lost leadership: %v", err)
+				cancel()
+			}
+		}
+	}
+	wg.Wait()
+
+	// NOTE(danilax86): The lock is released together with the session, so the connection is closed
+	// instead of being returned to the pool with the lock still held.
+	_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+}
@@ -0,0 +1,161 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"mr-metrics/internal/db"
+	"mr-metrics/internal/service/updater"
+)
+
+// leaderTimeout is longer than the retry interval of a replica waiting for the leadership.
+const leaderTimeout = 30 * time.Second
+
+// openElector opens a Postgres store given by TEST_DATABASE_URL, the test is skipped without it.
+func openElector(t *testing.T) updater.Elector {
+	t.Helper()
+
+	store, err := db.Open(postgresURL(t), false, true)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	elector, ok := store.(updater.Elector)
+	if !ok {
+		t.Fatalf("%T is not an Elector", store)
+	}
+	return elector
+}
+
+// lead runs Lead in the background and returns a channel receiving the context of every leadership term.
+func lead(ctx context.Context, t *testing.T, elector updater.Elector) <-chan context.Context {
+	t.Helper()
+
+	terms := make(chan context.Context, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = elector.Lead(ctx, func(ctx context.Context) {
+			terms <- ctx
+			<-ctx.Done()
+		})
+	}()
+	t.Cleanup(func() { <-done })
+	return terms
+}
+
+func receive(t *testing.T, terms <-chan context.Context) context.Context {
+	t.Helper()
+
+	select {
+	case ctx := <-terms:
+		return ctx
+	case <-time.After(leaderTimeout):
+		t.Fatal("leadership was not acquired")
+		return nil
+	}
+}
+
+//nolint:paralleltest // replicas compete for the same lock
+func TestLeadOneReplicaAtOnce(t *testing.T) {
+	first, second := openElector(t), openElector(t)
+
+	firstCtx, stopFirst := context.WithCancel(t.Context())
+	defer stopFirst()
+	firstTerm := receive(t, lead(firstCtx, t, first))
+
+	secondCtx, stopSecond := context.WithCancel(t.Context())
+	defer stopSecond()
+	secondTerms := lead(secondCtx, t, second)
+
+	select {
+	case <-secondTerms:
+		t.Fatal("second replica leads while the first one holds the lock")
+	case <-time.After(time.Second):
+	}
+
+	// The lock is released when the leader stops
+	stopFirst()
+	<-firstTerm.Done()
+	receive(t, secondTerms)
+}
+
+//nolint:paralleltest // replicas compete for the same lock
+func TestLeadStopsWhenConnectionIsLost(t *testing.T) {
+	leader := openElector(t)
+
+	ctx, stop := context.WithCancel(t.Context())
+	defer stop()
+	term := receive(t, lead(ctx, t, leader))
+
+	// Postgres releases the lock together with the session, so the leader must notice that its session is gone
+	conn, err := sql.Open("postgres", postgresURL(t))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(t.Context(), `
+		SELECT pg_terminate_backend(pid)
+		FROM pg_locks
+		WHERE locktype = 'advisory' AND granted AND pid <> pg_backend_pid()
+	`)
+	if err != nil {
+		t.Fatalf("failed to terminate leader session: %v", err)
+	}
+
+	select {
+	case <-term.Done():
+	case <-time.After(leaderTimeout):
+		t.Fatal("leader kept running after losing the lock")
+	}
+}
+
+//nolint:paralleltest // replicas compete for the same lock
+func TestTryLeadSkipsWhileAnotherReplicaLeads(t *testing.T) {
+	leader, other := openElector(t), openElector(t)
+
+	ctx, stop := context.WithCancel(t.Context())
+	defer stop()
+	term := receive(t, lead(ctx, t, leader))
+
+	led, err := other.TryLead(t.Context(), func(context.Context) {
+		t.Error("function ran while another replica leads")
+	})
+	if err != nil {
+		t.Fatalf("TryLead failed: %v", err)
+	}
+	if led {
+		t.Fatal("TryLead reported leading while another replica leads")
+	}
+
+	stop()
+	<-term.Done()
+
+	// The lock may be released a moment after the leader stops
+	deadline := time.Now().Add(leaderTimeout)
+	for {
+		ran := false
+		led, err := other.TryLead(t.Context(), func(context.Context) { ran = true })
+		if err != nil {
+			t.Fatalf("TryLead failed: %v", err)
+		}
+		if led {
+			if !ran {
+				t.Fatal("TryLead reported leading without running the function")
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("lock was not released by the stopped leader")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
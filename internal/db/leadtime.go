@@ -0,0 +1,101 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"mr-metrics/internal/model"
+	"slices"
+	"time"
+)
+
+const (
+	percentileMedian = 0.5
+	percentile75     = 0.75
+	percentile90     = 0.9
+)
+
+// leadTimeSample is the lead time of a single merge request.
+type leadTimeSample struct {
+	username    string
+	projectName string
+	leadTime    time.Duration
+}
+
+func scanLeadTimeSamples(rows *sql.Rows) ([]leadTimeSample, error) {
+	var samples []leadTimeSample
+	for rows.Next() {
+		var sample leadTimeSample
+		var createdAt, mergedAt time.Time
+		if err := rows.Scan(&sample.username, &sample.projectName, &createdAt, &mergedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		sample.leadTime = mergedAt.Sub(createdAt)
+		samples = append(samples, sample)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return samples, nil
+}
+
+func buildLeadTimeStats(samples []leadTimeSample) *model.LeadTimeStats {
+	developers := make(map[string][]time.Duration)
+	projects := make(map[string][]time.Duration)
+	total := make([]time.Duration, 0, len(samples))
+
+	for _, sample := range samples {
+		projectName := extractProjectName(sample.projectName)
+		developers[sample.username] = append(developers[sample.username], sample.leadTime)
+		projects[projectName] = append(projects[projectName], sample.leadTime)
+		total = append(total, sample.leadTime)
+	}
+
+	return &model.LeadTimeStats{
+		Developers: durationsByKey(developers),
+		Projects:   durationsByKey(projects),
+		Total:      newDurations(total),
+	}
+}
+
+func durationsByKey(m map[string][]time.Duration) map[string]model.Durations {
+	result := make(map[string]model.Durations, len(m))
+	for key, durations := range m {
+		result[key] = newDurations(durations)
+	}
+	return result
+}
+
+func newDurations(durations []time.Duration) model.Durations {
+	slices.Sort(durations)
+	return model.Durations{
+		Count:  len(durations),
+		Median: percentile(durations, percentileMedian),
+		P75:    percentile(durations, percentile75),
+		P90:    percentile(durations, percentile90),
+	}
+}
+
+// percentile returns the p-th percentile of sorted durations interpolating between the closest ranks,
+// the same way as percentile_cont in Postgres does.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := p * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower == upper {
+		return sorted[lower]
+	}
+
+	fraction := rank - float64(lower)
+	return sorted[lower] + time.Duration(fraction*float64(sorted[upper]-sorted[lower]))
+}
@@ -0,0 +1,506 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package db
+
+import (
+	"cmp"
+	"context"
+	"mr-metrics/internal/model"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps everything in memory, it is lost on restart.
+// It is used by the demo mode and doesn't need a database.
+type MemoryStore struct {
+	mu             sync.RWMutex
+	projects       map[int]*memoryProject
+	syncRuns       []model.SyncRun
+	excludeReverts bool
+}
+
+type memoryProject struct {
+	id int
+	// Source and ID of the project in its GitLab
+	source      string
+	gitlabID    int
+	name        string
+	lastUpdated time.Time
+	tracked     bool
+	// Merge requests by their IIDs
+	mrs map[int]model.MergeRequest
+}
+
+func NewMemoryStore(excludeReverts bool) *MemoryStore {
+	return &MemoryStore{
+		projects:       make(map[int]*memoryProject),
+		excludeReverts: excludeReverts,
+	}
+}
+
+func (m *MemoryStore) Close() error {
+	return nil
+}
+
+func (m *MemoryStore) GetLastUpdatedDate(_ context.Context, projectName string) (time.Time, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	project := m.projectByName(projectName)
+	if project == nil {
+		return time.Time{}, ErrNotFound
+	}
+	return project.lastUpdated, nil
+}
+
+func (m *MemoryStore) UpdateProjectCache(ctx context.Context, gitlabID int, projectName string, mrs []model.MergeRequest) error {
+	return m.updateProjectCache(ctx, gitlabID, projectName, mrs, nil)
+}
+
+// ReplaceProjectCache replaces the stored merge requests of a project merged since the given time
+// (all of them if it is zero) with the given ones, so the merge requests that are no longer returned
+// by GitLab are removed.
+func (m *MemoryStore) ReplaceProjectCache(
+	ctx context.Context, gitlabID int, projectName string, since time.Time, mrs []model.MergeRequest,
+) error {
+	return m.updateProjectCache(ctx, gitlabID, projectName, mrs, &since)
+}
+
+func (m *MemoryStore) updateProjectCache(
+	_ context.Context, gitlabID int, projectName string, mrs []model.MergeRequest, replaceSince *time.Time,
+) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var project *memoryProject
+	if gitlabID == 0 {
+		// The GitLab ID is unknown, so only an already stored project is updated
+		if project = m.projectByName(projectName); project == nil {
+			// The project was never stored
+			return nil
+		}
+	} else {
+		source, _ := model.SplitProjectName(projectName)
+		if project = m.projectBySource(source, gitlabID); project == nil {
+			project = &memoryProject{
+				id:       len(m.projects) + 1,
+				source:   source,
+				gitlabID: gitlabID,
+				tracked:  true,
+				mrs:      make(map[int]model.MergeRequest),
+			}
+			m.projects[project.id] = project
+		}
+	}
+
+	if replaceSince != nil {
+		if existing := m.projectByName(projectName); existing != nil {
+			for iid, mr := range existing.mrs {
+				if !mr.MergedAt.Before(*replaceSince) {
+					delete(existing.mrs, iid)
+				}
+			}
+		}
+	}
+
+	project.name = projectName
+	project.lastUpdated = time.Now()
+
+	for _, mr := range mrs {
+		mr.ProjectID = project.id
+		project.mrs[mr.IID] = mr
+	}
+	return nil
+}
+
+// SetTrackedProjects marks the given projects as tracked and all the others as not tracked,
+// so the stats of projects that are no longer updated are hidden.
+func (m *MemoryStore) SetTrackedProjects(_ context.Context, projectNames []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, project := range m.projects {
+		project.tracked = slices.Contains(projectNames, project.name)
+	}
+	return nil
+}
+
+// DeleteUsers removes the merge requests and the review events of the users.
+func (m *MemoryStore) DeleteUsers(_ context.Context, usernames []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, project := range m.projects {
+		for iid, mr := range project.mrs {
+			if slices.Contains(usernames, mr.Username) {
+				delete(project.mrs, iid)
+				continue
+			}
+			mr.Review.Events = slices.DeleteFunc(slices.Clone(mr.Review.Events), func(event model.ReviewEvent) bool {
+				return slices.Contains(usernames, event.Username)
+			})
+			project.mrs[iid] = mr
+		}
+	}
+	return nil
+}
+
+// GetTrackedProjects returns names of the projects which stats are shown.
+func (m *MemoryStore) GetTrackedProjects(_ context.Context) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var projectNames []string
+	for _, project := range m.projects {
+		if project.tracked {
+			projectNames = append(projectNames, project.name)
+		}
+	}
+	slices.Sort(projectNames)
+	return projectNames, nil
+}
+
+func (m *MemoryStore) GetAggregatedDataForDate(ctx context.Context, projectNames []string, targetDate time.Time) (*model.AggregatedStats, error) {
+	return m.GetAggregatedDataForRange(ctx, projectNames, time.Time{}, targetDate)
+}
+
+// GetAggregatedDataForRange returns the amount of merged requests per developer and project
+// that were merged between from (inclusive) and to (inclusive).
+func (m *MemoryStore) GetAggregatedDataForRange(
+	_ context.Context, projectNames []string, from, to time.Time,
+) (*model.AggregatedStats, error) {
+	type key struct {
+		username  string
+		projectID int
+	}
+
+	counts := make(map[key]int)
+	devTotals := make(map[string]int)
+	repoTotals := make(map[int]int)
+	names := make(map[int]string)
+
+	m.mu.RLock()
+	m.eachMergeRequest(projectNames, from, to, func(project *memoryProject, mr model.MergeRequest) {
+		if m.excluded(mr) {
+			return
+		}
+		counts[key{mr.Username, project.id}]++
+		devTotals[mr.Username]++
+		repoTotals[project.id]++
+		names[project.id] = project.name
+	})
+	m.mu.RUnlock()
+
+	rows := make([]aggregatedRow, 0, len(counts)+len(repoTotals))
+	for k, count := range counts {
+		rows = append(rows, aggregatedRow{
+			username:    k.username,
+			projectID:   k.projectID,
+			projectName: names[k.projectID],
+			count:       count,
+			devTotal:    devTotals[k.username],
+		})
+	}
+	for projectID, count := range repoTotals {
+		rows = append(rows, aggregatedRow{
+			username:    "TOTAL",
+			projectID:   projectID,
+			projectName: names[projectID],
+			count:       count,
+		})
+	}
+
+	return buildAggregatedStats(rows), nil
+}
+
+// GetLeadTimes returns the distribution of time between creating and merging merge requests
+// that were merged between from (inclusive) and to (inclusive).
+func (m *MemoryStore) GetLeadTimes(_ context.Context, projectNames []string, from, to time.Time) (*model.LeadTimeStats, error) {
+	var samples []leadTimeSample
+
+	m.mu.RLock()
+	m.eachMergeRequest(projectNames, from, to, func(project *memoryProject, mr model.MergeRequest) {
+		samples = append(samples, leadTimeSample{
+			username:    mr.Username,
+			projectName: project.name,
+			leadTime:    mr.MergedAt.Sub(mr.CreatedAt),
+		})
+	})
+	m.mu.RUnlock()
+
+	return buildLeadTimeStats(samples), nil
+}
+
+// GetReviewStats returns how fast merge requests merged between from (inclusive) and to (inclusive) were reviewed.
+func (m *MemoryStore) GetReviewStats(_ context.Context, projectNames []string, from, to time.Time) (*model.ReviewStats, error) {
+	var samples []reviewSample
+
+	m.mu.RLock()
+	m.eachMergeRequest(projectNames, from, to, func(project *memoryProject, mr model.MergeRequest) {
+		samples = append(samples, reviewSample{
+			projectName: project.name,
+			createdAt:   mr.CreatedAt,
+			rounds:      mr.Review.Rounds,
+			events:      mr.Review.Events,
+		})
+	})
+	m.mu.RUnlock()
+
+	return buildReviewStats(samples), nil
+}
+
+// GetReviewerDataForDate returns the amount of merge requests every reviewer approved and commented on
+// per project up to the target date.
+func (m *MemoryStore) GetReviewerDataForDate(
+	ctx context.Context, projectNames []string, targetDate time.Time,
+) (*model.ReviewerAggregatedStats, error) {
+	return m.GetReviewerDataForRange(ctx, projectNames, time.Time{}, targetDate)
+}
+
+// GetReviewerDataForRange returns the amount of merge requests every reviewer approved and commented on
+// per project between from (inclusive) and to (inclusive).
+func (m *MemoryStore) GetReviewerDataForRange(
+	_ context.Context, projectNames []string, from, to time.Time,
+) (*model.ReviewerAggregatedStats, error) {
+	type key struct {
+		username    string
+		projectName string
+	}
+	counts := make(map[key]model.ReviewCounts)
+
+	m.mu.RLock()
+	m.eachMergeRequest(projectNames, time.Time{}, time.Time{}, func(project *memoryProject, mr model.MergeRequest) {
+		// Every merge request is counted once per reviewer and kind of event
+		seen := make(map[model.ReviewEvent]struct{})
+		for _, event := range mr.Review.Events {
+			if event.CreatedAt.Before(from) || event.CreatedAt.After(to) {
+				continue
+			}
+
+			once := model.ReviewEvent{Username: event.Username, Kind: event.Kind}
+			if _, exists := seen[once]; exists {
+				continue
+			}
+			seen[once] = struct{}{}
+
+			k := key{event.Username, project.name}
+			c := counts[k]
+			switch event.Kind {
+			case model.ReviewEventApproval:
+				c.Approved++
+			case model.ReviewEventComment:
+				c.Commented++
+			}
+			counts[k] = c
+		}
+	})
+	m.mu.RUnlock()
+
+	samples := make([]reviewerCountsSample, 0, len(counts))
+	for k, c := range counts {
+		samples = append(samples, reviewerCountsSample{username: k.username, projectName: k.projectName, counts: c})
+	}
+
+	return buildReviewerAggregatedStats(samples), nil
+}
+
+// GetDeveloperStats returns the merged requests of a developer between from (inclusive) and to (inclusive)
+// split into weeks together with the largest and the most recent ones.
+func (m *MemoryStore) GetDeveloperStats(
+	_ context.Context, username string, projectNames []string, from, to time.Time,
+) (*model.DeveloperStats, error) {
+	var (
+		samples            []mergeSample
+		previousTotal      int
+		inRange, allMerged []model.MergeRequestSummary
+		previousFrom       = from.Add(-to.Sub(from))
+	)
+
+	m.mu.RLock()
+	m.eachMergeRequest(projectNames, time.Time{}, time.Time{}, func(project *memoryProject, mr model.MergeRequest) {
+		if mr.Username != username || m.excluded(mr) {
+			return
+		}
+
+		summary := newMergeRequestSummary(project, mr)
+		allMerged = append(allMerged, summary)
+
+		switch {
+		case !mr.MergedAt.Before(from) && !mr.MergedAt.After(to):
+			inRange = append(inRange, summary)
+			samples = append(samples, mergeSample{projectName: project.name, username: mr.Username, mergedAt: mr.MergedAt})
+		case !mr.MergedAt.Before(previousFrom) && mr.MergedAt.Before(from):
+			previousTotal++
+		}
+	})
+	m.mu.RUnlock()
+
+	slices.SortFunc(inRange, func(a, b model.MergeRequestSummary) int {
+		return cmp.Or(cmp.Compare(b.ChangesCount, a.ChangesCount), b.MergedAt.Compare(a.MergedAt))
+	})
+	slices.SortFunc(allMerged, func(a, b model.MergeRequestSummary) int {
+		return b.MergedAt.Compare(a.MergedAt)
+	})
+
+	return &model.DeveloperStats{
+		Username:      username,
+		Weekly:        buildWeeklyMerges(from, to, samples),
+		Total:         len(samples),
+		PreviousTotal: previousTotal,
+		LargestMRs:    inRange[:min(len(inRange), mrListLimit)],
+		RecentMRs:     allMerged[:min(len(allMerged), mrListLimit)],
+	}, nil
+}
+
+// GetProjectStats returns the merged requests of a project between from (inclusive) and to (inclusive)
+// split into weeks and contributors together with the latest sync runs of the project.
+func (m *MemoryStore) GetProjectStats(ctx context.Context, projectID int, from, to time.Time) (*model.ProjectStats, error) {
+	m.mu.RLock()
+	project, exists := m.projects[projectID]
+	if !exists {
+		m.mu.RUnlock()
+		return nil, ErrNotFound
+	}
+
+	stats := model.ProjectStats{ProjectID: projectID, ProjectName: project.name, LastUpdated: project.lastUpdated}
+	var samples []mergeSample
+	for _, mr := range project.mrs {
+		if mr.MergedAt.Before(from) || mr.MergedAt.After(to) || m.excluded(mr) {
+			continue
+		}
+		samples = append(samples, mergeSample{projectName: project.name, username: mr.Username, mergedAt: mr.MergedAt})
+	}
+	m.mu.RUnlock()
+
+	var err error
+	stats.SyncRuns, err = m.GetSyncRuns(ctx, stats.ProjectName, syncRunsLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	stats.Weekly = buildWeeklyMerges(from, to, samples)
+	stats.Total = len(samples)
+	stats.Contributors, stats.BusFactor = buildContributors(samples)
+	return &stats, nil
+}
+
+// AddSyncRun stores an attempt to update a project.
+func (m *MemoryStore) AddSyncRun(_ context.Context, run model.SyncRun) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.syncRuns = append(m.syncRuns, run)
+	return nil
+}
+
+// GetSyncRuns returns the latest sync runs of a project or of all projects if the project name is empty.
+func (m *MemoryStore) GetSyncRuns(_ context.Context, projectName string, limit int) ([]model.SyncRun, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var runs []model.SyncRun
+	for _, run := range m.syncRuns {
+		if projectName == "" || run.ProjectName == projectName {
+			runs = append(runs, run)
+		}
+	}
+	slices.SortFunc(runs, func(a, b model.SyncRun) int {
+		return b.StartedAt.Compare(a.StartedAt)
+	})
+	return runs[:min(len(runs), limit)], nil
+}
+
+// GetSyncStatus returns the last update time and the latest sync run of every tracked project
+// and of the projects that were never synced successfully.
+func (m *MemoryStore) GetSyncStatus(_ context.Context) ([]model.ProjectSyncStatus, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	projects := make([]syncedProject, 0, len(m.projects))
+	for _, project := range m.projects {
+		projects = append(projects, syncedProject{
+			id:          project.id,
+			name:        project.name,
+			lastUpdated: project.lastUpdated,
+			tracked:     project.tracked,
+		})
+	}
+
+	latest := make(map[string]model.SyncRun)
+	for _, run := range m.syncRuns {
+		if last, exists := latest[run.ProjectName]; !exists || run.StartedAt.After(last.StartedAt) {
+			latest[run.ProjectName] = run
+		}
+	}
+
+	latestRuns := make([]model.SyncRun, 0, len(latest))
+	for _, run := range latest {
+		latestRuns = append(latestRuns, run)
+	}
+
+	return buildSyncStatus(projects, latestRuns), nil
+}
+
+// projectByName returns the project with the given name or nil, the lock has to be held.
+func (m *MemoryStore) projectByName(projectName string) *memoryProject {
+	for _, project := range m.projects {
+		if project.name == projectName {
+			return project
+		}
+	}
+	return nil
+}
+
+// projectBySource returns the project with the given ID in the source or nil, the lock has to be held.
+func (m *MemoryStore) projectBySource(source string, gitlabID int) *memoryProject {
+	for _, project := range m.projects {
+		if project.source == source && project.gitlabID == gitlabID {
+			return project
+		}
+	}
+	return nil
+}
+
+// eachMergeRequest calls the function for merge requests of the given projects merged between from (inclusive)
+// and to (inclusive), zero bounds aren't checked. The read lock has to be held.
+func (m *MemoryStore) eachMergeRequest(
+	projectNames []string, from, to time.Time, fn func(project *memoryProject, mr model.MergeRequest),
+) {
+	for _, project := range m.projects {
+		if !slices.Contains(projectNames, project.name) {
+			continue
+		}
+		for _, mr := range project.mrs {
+			if !from.IsZero() && mr.MergedAt.Before(from) || !to.IsZero() && mr.MergedAt.After(to) {
+				continue
+			}
+			fn(project, mr)
+		}
+	}
+}
+
+// excluded reports whether the merge request isn't counted because it is a revert.
+func (m *MemoryStore) excluded(mr model.MergeRequest) bool {
+	return m.excludeReverts && isRevert(mr.Title)
+}
+
+func newMergeRequestSummary(project *memoryProject, mr model.MergeRequest) model.MergeRequestSummary {
+	return model.MergeRequestSummary{
+		ProjectName:  extractProjectName(project.name),
+		IID:          mr.IID,
+		Title:        mr.Title,
+		WebURL:       mr.WebURL,
+		ChangesCount: mr.ChangesCount,
+		MergedAt:     mr.MergedAt,
+		IsRevert:     isRevert(mr.Title),
+	}
+}
+
+// isRevert matches the is_revert column of the databases.
+func isRevert(title string) bool {
+	return strings.HasPrefix(title, "Revert")
+}
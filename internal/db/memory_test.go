@@ -0,0 +1,20 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package db_test
+
+import (
+	"testing"
+
+	"mr-metrics/internal/db"
+)
+
+func TestMemoryStore(t *testing.T) {
+	t.Parallel()
+
+	testStore(t, func(t *testing.T, excludeReverts bool) db.Store {
+		t.Helper()
+		return db.NewMemoryStore(excludeReverts)
+	})
+}
@@ -0,0 +1,204 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mr-metrics/migrations"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+const (
+	dialectPostgres = "postgres"
+	dialectSQLite   = "sqlite"
+)
+
+// Migrator manages the schema of a database explicitly with the migrations embedded into the binary.
+type Migrator struct {
+	db *sql.DB
+	m  *migrate.Migrate
+}
+
+// OpenMigrator connects to the database chosen by the scheme of the URL the same way as Open.
+func OpenMigrator(databaseURL string) (*Migrator, error) {
+	dialect, dsn, err := parseDatabaseURL(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := openDatabase(dialect, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := newMigrate(db, dialect)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Migrator{db: db, m: m}, nil
+}
+
+// Up applies all migrations that are not applied yet.
+func (m *Migrator) Up() error {
+	if err := m.m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to apply migrations: %w", err)
+	}
+	return nil
+}
+
+// Down rolls back the given amount of the latest applied migrations.
+func (m *Migrator) Down(steps int) error {
+	if err := m.m.Steps(-steps); err != nil {
+		return fmt.Errorf("failed to roll back migrations: %w", err)
+	}
+	return nil
+}
+
+// Version returns the version of the latest applied migration, zero if none were applied,
+// and whether it failed halfway.
+func (m *Migrator) Version() (uint, bool, error) {
+	version, dirty, err := m.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get schema version: %w", err)
+	}
+	return version, dirty, nil
+}
+
+// Force sets the version without running migrations, so a failed migration can be retried after fixing it by hand.
+func (m *Migrator) Force(version int) error {
+	if err := m.m.Force(version); err != nil {
+		return fmt.Errorf("failed to force schema version: %w", err)
+	}
+	return nil
+}
+
+// Close closes the database connections.
+func (m *Migrator) Close() error {
+	sourceErr, databaseErr := m.m.Close()
+	return errors.Join(sourceErr, databaseErr, m.db.Close())
+}
+
+// prepareSchema applies the migrations or only checks that they were applied.
+func prepareSchema(db *sql.DB, dialect string, autoMigrate bool) error {
+	if !autoMigrate {
+		return checkSchema(db, dialect)
+	}
+
+	if err := migrateUp(db, dialect); err != nil {
+		return fmt.Errorf("failed to run migrations: %w", err)
+	}
+	return nil
+}
+
+// migrateUp applies all migrations that are not applied yet.
+func migrateUp(db *sql.DB, dialect string) error {
+	m, err := newMigrate(db, dialect)
+	if err != nil {
+		return err
+	}
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to apply migrations: %w", err)
+	}
+
+	return nil
+}
+
+// checkSchema returns an error if migrations embedded into the binary are not applied to the database
+// or were migrated by a newer binary, it is used instead of applying them when the schema is managed
+// by the migrate command.
+func checkSchema(db *sql.DB, dialect string) error {
+	src, err := iofs.New(migrations.FS, dialect)
+	if err != nil {
+		return fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	latest, err := latestVersion(src)
+	if err != nil {
+		return err
+	}
+
+	m, err := newMigrate(db, dialect)
+	if err != nil {
+		return err
+	}
+
+	version, dirty, err := m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return fmt.Errorf("failed to get schema version: %w", err)
+	}
+
+	switch {
+	case dirty:
+		return fmt.Errorf("migration %d failed, fix the schema and run \"mr-metrics migrate force VERSION\"", version)
+	case version < latest:
+		return fmt.Errorf("schema version %d is older than %d, run \"mr-metrics migrate up\"", version, latest)
+	case version > latest:
+		// NOTE(danilax86): An older binary may misread columns added or changed by newer migrations.
+		return fmt.Errorf("schema version %d is newer than %d known by this binary, upgrade mr-metrics", version, latest)
+	default:
+		return nil
+	}
+}
+
+func newMigrate(db *sql.DB, dialect string) (*migrate.Migrate, error) {
+	src, err := iofs.New(migrations.FS, dialect)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	var driver database.Driver
+	switch dialect {
+	case dialectPostgres:
+		driver, err = postgres.WithInstance(db, &postgres.Config{})
+	case dialectSQLite:
+		driver, err = sqlite.WithInstance(db, &sqlite.Config{})
+	default:
+		err = fmt.Errorf("unsupported database %q", dialect)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migration driver: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, dialect, driver)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migration instance: %w", err)
+	}
+
+	return m, nil
+}
+
+// latestVersion returns the version of the last migration of the source.
+func latestVersion(src source.Driver) (uint, error) {
+	version, err := src.First()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read migrations: %w", err)
+		}
+		version = next
+	}
+}
@@ -5,23 +5,15 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
-	"github.com/golang-migrate/migrate/v4/database/postgres"
 	"github.com/lib/pq"
-	"mr-metrics/internal/consts"
 	"mr-metrics/internal/model"
-	"path"
 	"sort"
 	"strings"
 	"time"
-
-	"github.com/golang-migrate/migrate/v4"
-
-	// Blank import is necessary to enable the migrate library to use the file source driver,
-	// which is used to load migration scripts from files.
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 )
 
 const (
@@ -31,9 +23,27 @@ const (
 
 type PostgresStore struct {
 	db *sql.DB
+	// excludeReverts excludes merge requests which titles start with "Revert" from the counts
+	excludeReverts bool
+}
+
+// NewPostgresStore connects to Postgres and applies the migrations if autoMigrate is set,
+// otherwise it only checks that they were applied by the migrate command.
+func NewPostgresStore(connStr string, excludeReverts, autoMigrate bool) (*PostgresStore, error) {
+	db, err := openPostgres(connStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := prepareSchema(db, dialectPostgres, autoMigrate); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &PostgresStore{db: db, excludeReverts: excludeReverts}, nil
 }
 
-func NewPostgresStore(connStr string) (*PostgresStore, error) {
+func openPostgres(connStr string) (*sql.DB, error) {
 	db, err := sql.Open("postgres", connStr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
@@ -44,132 +54,684 @@ func NewPostgresStore(connStr string) (*PostgresStore, error) {
 	db.SetConnMaxLifetime(maxConnLifetime)
 
 	if err := db.Ping(); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	if err := runMigrations(db); err != nil {
-		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	return db, nil
+}
+
+// Close closes the database connections.
+func (p PostgresStore) Close() error {
+	return p.db.Close()
+}
+
+// GetLastUpdatedDate returns when the project was updated last time, sql.ErrNoRows if it was never stored
+// or was stored with older fields, so it's fetched from scratch.
+func (p PostgresStore) GetLastUpdatedDate(ctx context.Context, projectName string) (time.Time, error) {
+	var lastUpdated time.Time
+	err := p.db.QueryRowContext(ctx, `
+        SELECT last_updated
+        FROM projects
+        WHERE project_name = $1 AND history_version >= $2
+    `, projectName, historyVersion).Scan(&lastUpdated)
+	if err != nil {
+		return time.Time{}, err
 	}
+	return lastUpdated, nil
+}
+
+func (p PostgresStore) UpdateProjectCache(ctx context.Context, gitlabID int, projectName string, mrs []model.MergeRequest) error {
+	return p.updateProjectCache(ctx, gitlabID, projectName, mrs, nil)
+}
 
-	return &PostgresStore{db: db}, nil
+// ReplaceProjectCache replaces the stored merge requests of a project merged since the given time
+// (all of them if it is zero) with the given ones, so the merge requests that are no longer returned
+// by GitLab are removed.
+func (p PostgresStore) ReplaceProjectCache(
+	ctx context.Context, gitlabID int, projectName string, since time.Time, mrs []model.MergeRequest,
+) error {
+	return p.updateProjectCache(ctx, gitlabID, projectName, mrs, &since)
 }
 
-func runMigrations(db *sql.DB) error {
-	driver, err := postgres.WithInstance(db, &postgres.Config{})
+// updateProjectCache stores merge requests of a project removing the ones merged since replaceSince before
+// if it's given. gitlabID is the ID of the project in its GitLab source, the stored projects have their own IDs,
+// so the same IDs of different sources don't collide.
+func (p PostgresStore) updateProjectCache(
+	ctx context.Context, gitlabID int, projectName string, mrs []model.MergeRequest, replaceSince *time.Time,
+) error {
+	tx, err := p.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to create migration driver: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if replaceSince != nil {
+		// NOTE(danilax86): Review events are removed together with merge requests.
+		_, err = tx.ExecContext(ctx, `
+			DELETE FROM merge_requests
+			WHERE project_id IN (SELECT project_id FROM projects WHERE project_name = $1)
+			AND merged_at >= $2
+		`, projectName, *replaceSince)
+		if err != nil {
+			return fmt.Errorf("failed to remove merge requests: %w", err)
+		}
 	}
 
-	m, err := migrate.NewWithDatabaseInstance(
-		"file://"+path.Join("migrations"),
-		"postgres", driver)
+	var projectID int
+	if gitlabID == 0 {
+		// The GitLab ID is unknown, so only an already stored project is updated
+		err = tx.QueryRowContext(ctx, `
+			UPDATE projects
+			SET last_updated = NOW()
+			WHERE project_name = $1
+			RETURNING project_id
+		`, projectName).Scan(&projectID)
+		if errors.Is(err, sql.ErrNoRows) {
+			// The project was never stored
+			return nil
+		}
+	} else {
+		source, _ := model.SplitProjectName(projectName)
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO projects(source, gitlab_id, project_name, last_updated)
+			VALUES($1, $2, $3, NOW())
+			ON CONFLICT(source, gitlab_id) DO UPDATE SET
+				project_name = EXCLUDED.project_name,
+				last_updated = NOW()
+			RETURNING project_id
+		`, source, gitlabID, projectName).Scan(&projectID)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to create migration instance: %w", err)
+		return fmt.Errorf("failed to update project: %w", err)
 	}
 
-	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
-		return fmt.Errorf("failed to apply migrations: %w", err)
+	// NOTE(danilax86): Projects stored with older fields have no last update date, so they are fetched
+	// from scratch, only a partial rebuild doesn't store their whole history.
+	if replaceSince == nil || replaceSince.IsZero() {
+		if err := setHistoryVersion(ctx, tx, projectID); err != nil {
+			return err
+		}
 	}
 
-	return nil
+	if err := upsertMergeRequests(ctx, tx, projectID, mrs); err != nil {
+		return fmt.Errorf("failed to store merge requests: %w", err)
+	}
+
+	if err := rebuildDailyCumulativeCounts(ctx, tx, projectID, p.excludeReverts); err != nil {
+		return fmt.Errorf("failed to update daily cumulative counts: %w", err)
+	}
+
+	return tx.Commit()
 }
 
-func (p PostgresStore) GetLastUpdatedDate(projectName string) (time.Time, error) {
-	var lastUpdated time.Time
-	err := p.db.QueryRow(`
-        SELECT last_updated
-        FROM projects
-        WHERE project_name = $1
-    `, projectName).Scan(&lastUpdated)
+// SetTrackedProjects marks the given projects as tracked and all the others as not tracked,
+// so the stats of projects that are no longer updated are hidden.
+func (p PostgresStore) SetTrackedProjects(ctx context.Context, projectNames []string) error {
+	_, err := p.db.ExecContext(ctx, `
+		UPDATE projects
+		SET tracked = (project_name = ANY($1))
+	`, pq.Array(projectNames))
 	if err != nil {
-		return time.Time{}, err
+		return fmt.Errorf("failed to update tracked projects: %w", err)
 	}
-	return lastUpdated, nil
+	return nil
 }
 
-func (p PostgresStore) UpdateProjectCache(projectID int, projectName string, mrs []model.MergeRequest) error {
-	tx, err := p.db.Begin()
+// DeleteUsers removes the merge requests and the review events of the users
+// and rebuilds the daily counts of the projects they merged to.
+func (p PostgresStore) DeleteUsers(ctx context.Context, usernames []string) error {
+	if len(usernames) == 0 {
+		return nil
+	}
+
+	tx, err := p.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	_, err = tx.Exec(`
-		INSERT INTO projects(project_id, project_name, last_updated) 
-		VALUES($1, $2, NOW())
-		ON CONFLICT(project_id) DO UPDATE SET 
-			project_name = EXCLUDED.project_name,
-			last_updated = NOW()
-	`, projectID, projectName)
+	_, err = tx.ExecContext(ctx, `DELETE FROM review_events WHERE username = ANY($1)`, pq.Array(usernames))
 	if err != nil {
-		return fmt.Errorf("failed to update project: %w", err)
+		return fmt.Errorf("failed to delete review events: %w", err)
 	}
 
-	userDates := groupMRsByUserAndDate(mrs)
+	rows, err := tx.QueryContext(ctx, `
+		WITH deleted AS (
+			DELETE FROM merge_requests
+			WHERE username = ANY($1)
+			RETURNING project_id
+		)
+		SELECT DISTINCT project_id FROM deleted
+	`, pq.Array(usernames))
+	if err != nil {
+		return fmt.Errorf("failed to delete merge requests: %w", err)
+	}
+	projectIDs, err := scanInts(rows)
+	rows.Close()
+	if err != nil {
+		return err
+	}
 
-	if err := updateDailyCumulativeCounts(tx, userDates, projectID); err != nil {
-		return fmt.Errorf("failed to update daily cumulative counts: %w", err)
+	for _, projectID := range projectIDs {
+		if err := rebuildDailyCumulativeCounts(ctx, tx, projectID, p.excludeReverts); err != nil {
+			return err
+		}
 	}
 
 	return tx.Commit()
 }
 
-func (p PostgresStore) GetAggregatedDataForDate(projectNames []string, targetDate time.Time) (*model.AggregatedStats, error) {
-	rows, err := p.db.Query(getAggregatedDataSQL(), pq.Array(projectNames), targetDate)
+// GetTrackedProjects returns names of the projects which stats are shown.
+func (p PostgresStore) GetTrackedProjects(ctx context.Context) ([]string, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT project_name
+		FROM projects
+		WHERE tracked
+		ORDER BY project_name
+	`)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute query: %w", err)
 	}
 	defer rows.Close()
 
-	devTotals := make(map[string]int)
-	repoTotals := make(map[string]int)
-	projectsSet := make(map[string]struct{})
-	developerStats := make(map[string]map[string]int)
+	return scanStrings(rows)
+}
+
+func (p PostgresStore) GetAggregatedDataForDate(ctx context.Context, projectNames []string, targetDate time.Time) (*model.AggregatedStats, error) {
+	rows, err := p.db.QueryContext(ctx, getAggregatedDataSQL(), pq.Array(projectNames), targetDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	return scanAggregatedData(rows)
+}
+
+// GetAggregatedDataForRange returns the amount of merged requests per developer and project
+// that were merged between from (inclusive) and to (inclusive).
+func (p PostgresStore) GetAggregatedDataForRange(ctx context.Context, projectNames []string, from, to time.Time) (*model.AggregatedStats, error) {
+	rows, err := p.db.QueryContext(ctx, getAggregatedRangeDataSQL(), pq.Array(projectNames), from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	return scanAggregatedData(rows)
+}
+
+// GetLeadTimes returns the distribution of time between creating and merging merge requests
+// that were merged between from (inclusive) and to (inclusive).
+func (p PostgresStore) GetLeadTimes(ctx context.Context, projectNames []string, from, to time.Time) (*model.LeadTimeStats, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT mr.username, p.project_name, mr.created_at, mr.merged_at
+		FROM merge_requests mr
+		JOIN projects p ON mr.project_id = p.project_id
+		WHERE p.project_name = ANY($1)
+		AND mr.merged_at BETWEEN $2 AND $3
+	`, pq.Array(projectNames), from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	samples, err := scanLeadTimeSamples(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildLeadTimeStats(samples), nil
+}
+
+// GetReviewStats returns how fast merge requests merged between from (inclusive) and to (inclusive) were reviewed.
+func (p PostgresStore) GetReviewStats(ctx context.Context, projectNames []string, from, to time.Time) (*model.ReviewStats, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT p.project_name, mr.iid, mr.created_at, mr.review_rounds, e.username, e.kind, e.created_at, e.estimated
+		FROM merge_requests mr
+		JOIN projects p ON mr.project_id = p.project_id
+		LEFT JOIN review_events e ON e.project_id = mr.project_id AND e.mr_iid = mr.iid
+		WHERE p.project_name = ANY($1)
+		AND mr.merged_at BETWEEN $2 AND $3
+		ORDER BY p.project_name, mr.iid, e.created_at
+	`, pq.Array(projectNames), from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	samples, err := scanReviewSamples(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildReviewStats(samples), nil
+}
+
+// GetReviewerDataForDate returns the amount of merge requests every reviewer approved and commented on
+// per project up to the target date.
+func (p PostgresStore) GetReviewerDataForDate(
+	ctx context.Context, projectNames []string, targetDate time.Time,
+) (*model.ReviewerAggregatedStats, error) {
+	return p.GetReviewerDataForRange(ctx, projectNames, time.Time{}, targetDate)
+}
+
+// GetReviewerDataForRange returns the amount of merge requests every reviewer approved and commented on
+// per project between from (inclusive) and to (inclusive).
+func (p PostgresStore) GetReviewerDataForRange(
+	ctx context.Context, projectNames []string, from, to time.Time,
+) (*model.ReviewerAggregatedStats, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT
+			e.username,
+			p.project_name,
+			COUNT(DISTINCT e.mr_iid) FILTER (WHERE e.kind = 'approval'),
+			COUNT(DISTINCT e.mr_iid) FILTER (WHERE e.kind = 'comment')
+		FROM review_events e
+		JOIN projects p ON e.project_id = p.project_id
+		WHERE p.project_name = ANY($1)
+		AND e.created_at BETWEEN $2 AND $3
+		GROUP BY e.username, p.project_name
+	`, pq.Array(projectNames), from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	samples, err := scanReviewerCountsSamples(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildReviewerAggregatedStats(samples), nil
+}
+
+// GetDeveloperStats returns the merged requests of a developer between from (inclusive) and to (inclusive)
+// split into weeks together with the largest of them, and the most recent merged requests of the developer.
+func (p PostgresStore) GetDeveloperStats(
+	ctx context.Context, username string, projectNames []string, from, to time.Time,
+) (*model.DeveloperStats, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT p.project_name, mr.username, mr.merged_at
+		FROM merge_requests mr
+		JOIN projects p ON mr.project_id = p.project_id
+		WHERE mr.username = $1
+		AND p.project_name = ANY($2)
+		AND mr.merged_at BETWEEN $3 AND $4
+		AND NOT (mr.is_revert AND $5)
+	`, username, pq.Array(projectNames), from, to, p.excludeReverts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	samples, err := scanMergeSamples(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	var previousTotal int
+	err = p.db.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM merge_requests mr
+		JOIN projects p ON mr.project_id = p.project_id
+		WHERE mr.username = $1
+		AND p.project_name = ANY($2)
+		AND mr.merged_at >= $3 AND mr.merged_at < $4
+		AND NOT (mr.is_revert AND $5)
+	`, username, pq.Array(projectNames), from.Add(-to.Sub(from)), from, p.excludeReverts).Scan(&previousTotal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count previous merge requests: %w", err)
+	}
+
+	largest, err := p.getMergeRequestSummaries(ctx, `
+		WHERE mr.username = $1 AND p.project_name = ANY($2) AND mr.merged_at BETWEEN $3 AND $4
+		AND NOT (mr.is_revert AND $5)
+		ORDER BY mr.changes_count DESC, mr.merged_at DESC
+		LIMIT $6
+	`, username, pq.Array(projectNames), from, to, p.excludeReverts, mrListLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	recent, err := p.getMergeRequestSummaries(ctx, `
+		WHERE mr.username = $1 AND p.project_name = ANY($2)
+		AND NOT (mr.is_revert AND $3)
+		ORDER BY mr.merged_at DESC
+		LIMIT $4
+	`, username, pq.Array(projectNames), p.excludeReverts, mrListLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.DeveloperStats{
+		Username:      username,
+		Weekly:        buildWeeklyMerges(from, to, samples),
+		Total:         len(samples),
+		PreviousTotal: previousTotal,
+		LargestMRs:    largest,
+		RecentMRs:     recent,
+	}, nil
+}
+
+// GetProjectStats returns the merged requests of a project between from (inclusive) and to (inclusive)
+// split into weeks and contributors together with the latest sync runs of the project.
+func (p PostgresStore) GetProjectStats(ctx context.Context, projectID int, from, to time.Time) (*model.ProjectStats, error) {
+	stats := model.ProjectStats{ProjectID: projectID}
+	err := p.db.QueryRowContext(ctx, `
+		SELECT project_name, last_updated
+		FROM projects
+		WHERE project_id = $1
+	`, projectID).Scan(&stats.ProjectName, &stats.LastUpdated)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	}
+
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT p.project_name, mr.username, mr.merged_at
+		FROM merge_requests mr
+		JOIN projects p ON mr.project_id = p.project_id
+		WHERE mr.project_id = $1
+		AND mr.merged_at BETWEEN $2 AND $3
+		AND NOT (mr.is_revert AND $4)
+	`, projectID, from, to, p.excludeReverts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	samples, err := scanMergeSamples(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	stats.SyncRuns, err = p.GetSyncRuns(ctx, stats.ProjectName, syncRunsLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	stats.Weekly = buildWeeklyMerges(from, to, samples)
+	stats.Total = len(samples)
+	stats.Contributors, stats.BusFactor = buildContributors(samples)
+	return &stats, nil
+}
+
+// AddSyncRun stores an attempt to update a project.
+func (p PostgresStore) AddSyncRun(ctx context.Context, run model.SyncRun) error {
+	_, err := p.db.ExecContext(ctx, `
+		INSERT INTO sync_runs (project_name, started_at, finished_at, since, merge_requests, error)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, run.ProjectName, run.StartedAt.UTC(), run.FinishedAt.UTC(),
+		sql.NullTime{Time: run.Since.UTC(), Valid: !run.Since.IsZero()},
+		run.MergeRequests,
+		sql.NullString{String: run.Error, Valid: run.Error != ""},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add sync run: %w", err)
+	}
+	return nil
+}
 
+// AddSyncRequest stores a requested sync for the leader unless the given amount of requests is waiting already.
+// It reports whether the request was stored.
+func (p PostgresStore) AddSyncRequest(ctx context.Context, request model.SyncRequest, limit int) (bool, error) {
+	result, err := p.db.ExecContext(ctx, `
+		INSERT INTO sync_requests (project_name, since, rebuild)
+		SELECT $1, $2::TIMESTAMP, $3::BOOLEAN
+		WHERE (SELECT COUNT(*) FROM sync_requests) < $4
+	`, request.ProjectName, sql.NullTime{Time: request.Since.UTC(), Valid: !request.Since.IsZero()}, request.Rebuild, limit)
+	if err != nil {
+		return false, fmt.Errorf("failed to add sync request: %w", err)
+	}
+
+	added, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to add sync request: %w", err)
+	}
+	return added > 0, nil
+}
+
+// TakeSyncRequest removes the oldest requested sync and returns it, false if there are none.
+func (p PostgresStore) TakeSyncRequest(ctx context.Context) (model.SyncRequest, bool, error) {
+	return scanSyncRequest(p.db.QueryRowContext(ctx, `
+		DELETE FROM sync_requests
+		WHERE id = (SELECT MIN(id) FROM sync_requests)
+		RETURNING project_name, since, rebuild
+	`))
+}
+
+// GetSyncRuns returns the latest sync runs of a project or of all projects if the project name is empty.
+func (p PostgresStore) GetSyncRuns(ctx context.Context, projectName string, limit int) ([]model.SyncRun, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT project_name, started_at, finished_at, since, merge_requests, error
+		FROM sync_runs
+		WHERE $1 = '' OR project_name = $1
+		ORDER BY started_at DESC
+		LIMIT $2
+	`, projectName, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	return scanSyncRuns(rows)
+}
+
+// GetSyncStatus returns the last update time and the latest sync run of every tracked project
+// and of the projects that were never synced successfully.
+func (p PostgresStore) GetSyncStatus(ctx context.Context) ([]model.ProjectSyncStatus, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT project_id, project_name, last_updated, tracked
+		FROM projects
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	projects, err := scanSyncedProjects(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	runRows, err := p.db.QueryContext(ctx, `
+		SELECT DISTINCT ON (project_name) project_name, started_at, finished_at, since, merge_requests, error
+		FROM sync_runs
+		ORDER BY project_name, started_at DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer runRows.Close()
+
+	latestRuns, err := scanSyncRuns(runRows)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildSyncStatus(projects, latestRuns), nil
+}
+
+// getMergeRequestSummaries returns merge requests selected by the condition and the order of the query tail.
+func (p PostgresStore) getMergeRequestSummaries(ctx context.Context, tail string, args ...any) ([]model.MergeRequestSummary, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT p.project_name, mr.iid, mr.title, mr.web_url, mr.changes_count, mr.merged_at, mr.is_revert
+		FROM merge_requests mr
+		JOIN projects p ON mr.project_id = p.project_id
+	`+tail, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	return scanMergeRequestSummaries(rows)
+}
+
+func scanMergeRequestSummaries(rows *sql.Rows) ([]model.MergeRequestSummary, error) {
+	var summaries []model.MergeRequestSummary
 	for rows.Next() {
-		var devTotal int
-		var count int
-		var username, fullProjectName string
+		var summary model.MergeRequestSummary
+		err := rows.Scan(
+			&summary.ProjectName, &summary.IID, &summary.Title, &summary.WebURL, &summary.ChangesCount, &summary.MergedAt,
+			&summary.IsRevert,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		summary.ProjectName = extractProjectName(summary.ProjectName)
+		summaries = append(summaries, summary)
+	}
 
-		if err := rows.Scan(&username, &fullProjectName, &count, &devTotal); err != nil {
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return summaries, nil
+}
+
+func scanMergeSamples(rows *sql.Rows) ([]mergeSample, error) {
+	var samples []mergeSample
+	for rows.Next() {
+		var sample mergeSample
+		if err := rows.Scan(&sample.projectName, &sample.username, &sample.mergedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
+		samples = append(samples, sample)
+	}
 
-		projectName := extractProjectName(fullProjectName)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return samples, nil
+}
+
+func scanStrings(rows *sql.Rows) ([]string, error) {
+	var values []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		values = append(values, value)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return values, nil
+}
+
+func scanInts(rows *sql.Rows) ([]int, error) {
+	var values []int
+	for rows.Next() {
+		var value int
+		if err := rows.Scan(&value); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		values = append(values, value)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return values, nil
+}
+
+func scanSyncRuns(rows *sql.Rows) ([]model.SyncRun, error) {
+	var runs []model.SyncRun
+	for rows.Next() {
+		var run model.SyncRun
+		var since sql.NullTime
+		var runError sql.NullString
+		err := rows.Scan(&run.ProjectName, &run.StartedAt, &run.FinishedAt, &since, &run.MergeRequests, &runError)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		run.Since = since.Time
+		run.Error = runError.String
+		runs = append(runs, run)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return runs, nil
+}
+
+// aggregatedRow is the amount of merged requests of a developer in a project,
+// rows of the "TOTAL" developer are the totals of projects.
+type aggregatedRow struct {
+	username    string
+	projectID   int
+	projectName string
+	count       int
+	devTotal    int
+}
+
+func scanAggregatedData(rows *sql.Rows) (*model.AggregatedStats, error) {
+	var aggregatedRows []aggregatedRow
+	for rows.Next() {
+		var row aggregatedRow
+		if err := rows.Scan(&row.username, &row.projectID, &row.projectName, &row.count, &row.devTotal); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		aggregatedRows = append(aggregatedRows, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return buildAggregatedStats(aggregatedRows), nil
+}
+
+func buildAggregatedStats(rows []aggregatedRow) *model.AggregatedStats {
+	devTotals := make(map[string]int)
+	repoTotals := make(map[string]int)
+	projectsSet := make(map[string]struct{})
+	projectIDs := make(map[string]int)
+	developerStats := make(map[string]map[string]int)
+
+	for _, row := range rows {
+		projectName := extractProjectName(row.projectName)
 		projectsSet[projectName] = struct{}{}
+		projectIDs[projectName] = row.projectID
 
-		if username == "TOTAL" {
+		if row.username == "TOTAL" {
 			// Repository total merged mrs row
-			repoTotals[projectName] = count
+			repoTotals[projectName] = row.count
 		} else {
-			if _, exists := developerStats[username]; !exists {
-				developerStats[username] = make(map[string]int)
+			if _, exists := developerStats[row.username]; !exists {
+				developerStats[row.username] = make(map[string]int)
 			}
-			devTotals[username] = devTotal
-			developerStats[username][projectName] = count
+			devTotals[row.username] = row.devTotal
+			developerStats[row.username][projectName] = row.count
 		}
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("row iteration error: %w", err)
-	}
-
 	projects := sortedKeys(projectsSet)
 
 	return &model.AggregatedStats{
 		Developers: developerStats,
 		Projects:   projects,
+		ProjectIDs: projectIDs,
 		DevTotals:  devTotals,
 		RepoTotals: repoTotals,
-	}, nil
+	}
 }
 
+// extractProjectName returns the short name of a project shown in the tables, the namespace is dropped,
+// but the source is kept, e.g. "oss:group/repo" becomes "oss:repo".
 func extractProjectName(fullName string) string {
-	parts := strings.Split(fullName, "/")
-	if len(parts) > 1 {
-		return parts[len(parts)-1]
-	}
-	return fullName
+	source, path := model.SplitProjectName(fullName)
+	parts := strings.Split(path, "/")
+	return model.QualifiedProjectName(source, parts[len(parts)-1])
 }
 
 func sortedKeys(m map[string]struct{}) []string {
@@ -181,113 +743,88 @@ func sortedKeys(m map[string]struct{}) []string {
 	return keys
 }
 
-func groupMRsByUserAndDate(mrs []model.MergeRequest) map[string]map[time.Time]int {
-	userDates := make(map[string]map[time.Time]int)
+// upsertMergeRequests inserts the merge requests or updates them if they were already stored,
+// so fetching the same merge request several times doesn't affect the counts.
+func upsertMergeRequests(ctx context.Context, tx *sql.Tx, projectID int, mrs []model.MergeRequest) error {
 	for _, mr := range mrs {
-		date := mr.MergedAt.UTC().Truncate(consts.OneDay)
-		if _, exists := userDates[mr.Username]; !exists {
-			userDates[mr.Username] = make(map[time.Time]int)
+		labels := mr.Labels
+		if labels == nil {
+			labels = []string{}
 		}
-		userDates[mr.Username][date]++
-	}
-	return userDates
-}
 
-// updateDailyCumulativeCounts updates the daily cumulative counts of merge requests for each user in a project.
-func updateDailyCumulativeCounts(tx *sql.Tx, userDates map[string]map[time.Time]int, projectID int) error {
-	for username, dates := range userDates {
-		sortedDates := getSortedDates(dates)
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO merge_requests (
+				project_id, iid, title, username, source_branch, target_branch, labels, web_url, created_at, merged_at,
+				review_rounds, changes_count
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+			ON CONFLICT (project_id, iid) DO UPDATE SET
+				title = EXCLUDED.title,
+				username = EXCLUDED.username,
+				source_branch = EXCLUDED.source_branch,
+				target_branch = EXCLUDED.target_branch,
+				labels = EXCLUDED.labels,
+				web_url = EXCLUDED.web_url,
+				created_at = EXCLUDED.created_at,
+				merged_at = EXCLUDED.merged_at,
+				review_rounds = EXCLUDED.review_rounds,
+				changes_count = EXCLUDED.changes_count
+		`, projectID, mr.IID, mr.Title, mr.Username, mr.SourceBranch, mr.TargetBranch,
+			pq.Array(labels), mr.WebURL, mr.CreatedAt.UTC(), mr.MergedAt.UTC(), mr.Review.Rounds, mr.ChangesCount)
+		if err != nil {
+			return fmt.Errorf("failed to upsert merge request !%d: %w", mr.IID, err)
+		}
 
-		if err := updateUserCounts(tx, username, projectID, sortedDates, dates); err != nil {
-			return err
+		if err := replaceReviewEvents(ctx, tx, projectID, mr); err != nil {
+			return fmt.Errorf("failed to store review of merge request !%d: %w", mr.IID, err)
 		}
 	}
 	return nil
 }
 
-// getSortedDates returns a slice of dates sorted in ascending order.
-func getSortedDates(dates map[time.Time]int) []time.Time {
-	sortedDates := make([]time.Time, 0, len(dates))
-	for date := range dates {
-		sortedDates = append(sortedDates, date)
-	}
-	sort.Slice(sortedDates, func(i, j int) bool {
-		return sortedDates[i].Before(sortedDates[j])
-	})
-	return sortedDates
-}
-
-// updateUserCounts updates the cumulative counts for a specific user.
-func updateUserCounts(tx *sql.Tx, username string, projectID int, sortedDates []time.Time, dates map[time.Time]int) error {
-	// NOTE(d.gorelko): Get the latest cumulative count before the earliest date in the current batch.
-	cumulative := 0
-	if len(sortedDates) > 0 {
-		earliestDate := sortedDates[0]
-		err := tx.QueryRow(`
-			SELECT merge_count
-			FROM merged_mrs
-			WHERE username = $1 AND project_id = $2 AND merged_at < $3
-			ORDER BY merged_at DESC
-			LIMIT 1
-		`, username, projectID, earliestDate).Scan(&cumulative)
-		if err != nil && !errors.Is(err, sql.ErrNoRows) {
-			return fmt.Errorf("failed to get previous cumulative count: %w", err)
-		}
+// replaceReviewEvents replaces the stored review events of a merge request with the fetched ones.
+func replaceReviewEvents(ctx context.Context, tx *sql.Tx, projectID int, mr model.MergeRequest) error {
+	_, err := tx.ExecContext(ctx, `
+		DELETE FROM review_events
+		WHERE project_id = $1 AND mr_iid = $2
+	`, projectID, mr.IID)
+	if err != nil {
+		return fmt.Errorf("failed to delete review events: %w", err)
 	}
 
-	for _, date := range sortedDates {
-		cumulative += dates[date]
-
-		if err := updateOrInsertCount(tx, username, projectID, date, cumulative); err != nil {
-			return err
+	for _, event := range mr.Review.Events {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO review_events (project_id, mr_iid, username, kind, created_at, estimated)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, projectID, mr.IID, event.Username, event.Kind, event.CreatedAt.UTC(), event.Estimated)
+		if err != nil {
+			return fmt.Errorf("failed to add review event: %w", err)
 		}
 	}
 	return nil
 }
 
-// updateOrInsertCount updates an existing row or inserts a new one for the given date.
-func updateOrInsertCount(tx *sql.Tx, username string, projectID int, date time.Time, cumulative int) error {
-	var existingCount int
-	err := tx.QueryRow(`
-		SELECT merge_count
-		FROM merged_mrs
-		WHERE username = $1 AND project_id = $2 AND merged_at = $3
-	`, username, projectID, date).Scan(&existingCount)
-
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
-		return fmt.Errorf("failed to check existing row: %w", err)
-	}
-
-	if existingCount > 0 {
-		return updateExistingCount(tx, username, projectID, date, cumulative)
+// rebuildDailyCumulativeCounts derives the daily cumulative counts of merge requests for each user in a project
+// from the stored merge requests.
+func rebuildDailyCumulativeCounts(ctx context.Context, tx *sql.Tx, projectID int, excludeReverts bool) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM merged_mrs WHERE project_id = $1`, projectID); err != nil {
+		return fmt.Errorf("failed to delete daily counts: %w", err)
 	}
 
-	return insertNewCount(tx, username, projectID, date, cumulative)
-}
-
-// updateExistingCount updates an existing row in the database.
-func updateExistingCount(tx *sql.Tx, username string, projectID int, date time.Time, cumulative int) error {
-	_, err := tx.Exec(`
-		UPDATE merged_mrs
-		SET merge_count = $1
-		WHERE username = $2 AND project_id = $3 AND merged_at = $4
-	`, cumulative, username, projectID, date)
-
-	if err != nil {
-		return fmt.Errorf("failed to update existing row: %w", err)
-	}
-	return nil
-}
-
-// insertNewCount inserts a new row into the database.
-func insertNewCount(tx *sql.Tx, username string, projectID int, date time.Time, cumulative int) error {
-	_, err := tx.Exec(`
+	_, err := tx.ExecContext(ctx, `
 		INSERT INTO merged_mrs (username, project_id, merge_count, merged_at)
-		VALUES ($1, $2, $3, $4)
-	`, username, projectID, cumulative, date)
-
+		SELECT
+			username,
+			project_id,
+			SUM(COUNT(*)) OVER (PARTITION BY username ORDER BY date_trunc('day', merged_at)),
+			date_trunc('day', merged_at)
+		FROM merge_requests
+		WHERE project_id = $1
+		AND NOT (is_revert AND $2)
+		GROUP BY username, project_id, date_trunc('day', merged_at)
+	`, projectID, excludeReverts)
 	if err != nil {
-		return fmt.Errorf("failed to add row: %w", err)
+		return fmt.Errorf("failed to insert daily counts: %w", err)
 	}
 	return nil
 }
@@ -297,6 +834,7 @@ func getAggregatedDataSQL() string {
 		WITH latest_data AS (
             SELECT DISTINCT ON (m.username, p.project_id)
                 m.username,
+                p.project_id,
                 p.project_name,
                 m.merge_count
             FROM merged_mrs m
@@ -304,7 +842,48 @@ func getAggregatedDataSQL() string {
             WHERE p.project_name = ANY($1)
             AND m.merged_at <= $2
             ORDER BY m.username, p.project_id, m.merged_at DESC
+        ),` + getAggregatedTotalsSQL()
+}
+
+// getAggregatedRangeDataSQL subtracts the cumulative counts known before the start of the range
+// from the cumulative counts known at the end of it.
+func getAggregatedRangeDataSQL() string {
+	return `
+		WITH latest_to AS (
+            SELECT DISTINCT ON (m.username, p.project_id)
+                m.username,
+                p.project_id,
+                p.project_name,
+                m.merge_count
+            FROM merged_mrs m
+            JOIN projects p ON m.project_id = p.project_id
+            WHERE p.project_name = ANY($1)
+            AND m.merged_at <= $3
+            ORDER BY m.username, p.project_id, m.merged_at DESC
+        ),
+		latest_from AS (
+            SELECT DISTINCT ON (m.username, m.project_id)
+                m.username,
+                m.project_id,
+                m.merge_count
+            FROM merged_mrs m
+            WHERE m.merged_at < $2
+            ORDER BY m.username, m.project_id, m.merged_at DESC
         ),
+		latest_data AS (
+			SELECT
+				t.username,
+				t.project_id,
+				t.project_name,
+				t.merge_count - COALESCE(f.merge_count, 0) AS merge_count
+			FROM latest_to t
+			LEFT JOIN latest_from f ON f.username = t.username AND f.project_id = t.project_id
+			WHERE t.merge_count - COALESCE(f.merge_count, 0) > 0
+		),` + getAggregatedTotalsSQL()
+}
+
+func getAggregatedTotalsSQL() string {
+	return `
 		user_totals AS (
 			SELECT
 				username,
@@ -314,13 +893,15 @@ func getAggregatedDataSQL() string {
 		),
 		repo_totals AS (
 			SELECT
+				project_id,
 				project_name,
 				SUM(merge_count) as repo_total_mrs
 			FROM latest_data
-			GROUP BY project_name
+			GROUP BY project_id, project_name
 		)
         SELECT 
             p.username,
+            p.project_id,
             p.project_name,
             p.merge_count,
             t.user_total_mrs
@@ -331,6 +912,7 @@ func getAggregatedDataSQL() string {
 
 		SELECT
 			'TOTAL' as username,
+			project_id,
 			project_name,
 			repo_total_mrs as merge_count,
 			0 as user_total
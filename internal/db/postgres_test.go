@@ -0,0 +1,180 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package db_test
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"mr-metrics/internal/db"
+)
+
+// sourceMigrationVersion is the version of the migration storing the GitLab ID of a project apart from its ID.
+const sourceMigrationVersion = 11
+
+// postgresURL returns TEST_DATABASE_URL, the test is skipped without it.
+func postgresURL(t *testing.T) string {
+	t.Helper()
+
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	return databaseURL
+}
+
+// newPostgresSchema creates a schema dropped at the end of the test and returns the URL of the database using it,
+// so tests running in parallel don't share tables.
+func newPostgresSchema(t *testing.T) string {
+	t.Helper()
+
+	databaseURL := postgresURL(t)
+	conn, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	schema := "test_" + strings.ToLower(rand.Text())
+	if _, err := conn.ExecContext(t.Context(), `CREATE SCHEMA `+schema); err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		// The context of the test is done before its cleanup
+		if _, err := conn.ExecContext(context.Background(), `DROP SCHEMA `+schema+` CASCADE`); err != nil {
+			t.Errorf("failed to drop schema: %v", err)
+		}
+	})
+
+	return withSearchPath(t, databaseURL, schema)
+}
+
+// withSearchPath adds the schema to the connection parameters given as a URL or as key=value pairs.
+func withSearchPath(t *testing.T, databaseURL, schema string) string {
+	t.Helper()
+
+	if !strings.Contains(databaseURL, "://") {
+		return databaseURL + " search_path=" + schema
+	}
+
+	u, err := url.Parse(databaseURL)
+	if err != nil {
+		t.Fatalf("failed to parse TEST_DATABASE_URL: %v", err)
+	}
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// openPostgres opens a store in a new schema and returns it with a separate connection to the schema.
+func openPostgres(t *testing.T, excludeReverts bool) (db.Store, *sql.DB) {
+	t.Helper()
+
+	databaseURL := newPostgresSchema(t)
+	store, err := db.Open(databaseURL, excludeReverts, true)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	conn, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return store, conn
+}
+
+func TestPostgresStore(t *testing.T) {
+	t.Parallel()
+	postgresURL(t)
+
+	testStore(t, func(t *testing.T, excludeReverts bool) db.Store {
+		t.Helper()
+		store, _ := openPostgres(t, excludeReverts)
+		return store
+	})
+}
+
+func TestPostgresStoreHistoryVersion(t *testing.T) {
+	t.Parallel()
+
+	store, conn := openPostgres(t, false)
+	fillStore(t, store)
+
+	testHistoryVersion(t, store, conn)
+}
+
+func TestPostgresSourceMigration(t *testing.T) {
+	t.Parallel()
+
+	databaseURL := newPostgresSchema(t)
+	migrator, err := db.OpenMigrator(databaseURL)
+	if err != nil {
+		t.Fatalf("failed to open migrator: %v", err)
+	}
+	defer migrator.Close()
+
+	if err := migrator.Up(); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	version, _, err := migrator.Version()
+	if err != nil {
+		t.Fatalf("Version failed: %v", err)
+	}
+	if err := migrator.Down(int(version) - sourceMigrationVersion + 1); err != nil {
+		t.Fatalf("Down failed: %v", err)
+	}
+
+	// Before the migration the ID of a project was its GitLab ID
+	const storedID = 42
+	conn, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer conn.Close()
+	_, err = conn.ExecContext(t.Context(), `
+		INSERT INTO projects (project_id, project_name, last_updated)
+		VALUES ($1, $2, NOW())
+	`, storedID, webProject)
+	if err != nil {
+		t.Fatalf("failed to store project: %v", err)
+	}
+
+	if err := migrator.Up(); err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+
+	store, err := db.Open(databaseURL, false, false)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer store.Close()
+
+	// The stored project is found by its GitLab ID, and new ones get the IDs after it
+	if err := store.UpdateProjectCache(t.Context(), storedID, webProject, webMergeRequests()); err != nil {
+		t.Fatalf("failed to update %s: %v", webProject, err)
+	}
+	if err := store.UpdateProjectCache(t.Context(), apiID, apiProject, apiMergeRequests()); err != nil {
+		t.Fatalf("failed to update %s: %v", apiProject, err)
+	}
+	if err := store.SetTrackedProjects(t.Context(), []string{webProject, apiProject}); err != nil {
+		t.Fatalf("failed to set tracked projects: %v", err)
+	}
+
+	stats := getAggregatedData(t, store, time.Time{}, base().Add(365*24*time.Hour))
+	if stats.ProjectIDs["web"] != storedID || stats.ProjectIDs["api"] != storedID+1 {
+		t.Errorf("ProjectIDs = %v, want web %d and api %d", stats.ProjectIDs, storedID, storedID+1)
+	}
+	assertDevelopers(t, stats, map[string]map[string]int{alice: {"web": 2}, bob: {"web": 1, "api": 1}})
+}
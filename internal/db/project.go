@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package db
+
+import (
+	"cmp"
+	"errors"
+	"mr-metrics/internal/model"
+	"slices"
+)
+
+const (
+	// topContributors is the amount of contributors shown separately, the rest are shown as "others".
+	topContributors   = 5
+	othersContributor = "others"
+	// busFactorShare is the share of merged requests the bus factor contributors have to make.
+	busFactorShare = 0.5
+	// syncRunsLimit is the amount of the latest sync runs shown for a project.
+	syncRunsLimit = 10
+)
+
+// ErrNotFound is returned when the requested entity is not stored.
+var ErrNotFound = errors.New("not found")
+
+// buildContributors returns the shares of top contributors and the bus factor of a project.
+func buildContributors(samples []mergeSample) ([]model.ContributorShare, int) {
+	counts := make(map[string]int)
+	for _, sample := range samples {
+		counts[sample.username]++
+	}
+
+	contributors := make([]model.ContributorShare, 0, len(counts))
+	for username, count := range counts {
+		contributors = append(contributors, model.ContributorShare{
+			Username: username,
+			Count:    count,
+			Share:    float64(count) / float64(len(samples)),
+		})
+	}
+	slices.SortFunc(contributors, func(a, b model.ContributorShare) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Username, b.Username))
+	})
+
+	busFactor := 0
+	covered := 0.0
+	for _, contributor := range contributors {
+		if covered > busFactorShare {
+			break
+		}
+		covered += contributor.Share
+		busFactor++
+	}
+
+	if len(contributors) <= topContributors {
+		return contributors, busFactor
+	}
+
+	others := model.ContributorShare{Username: othersContributor}
+	for _, contributor := range contributors[topContributors:] {
+		others.Count += contributor.Count
+		others.Share += contributor.Share
+	}
+	return append(contributors[:topContributors], others), busFactor
+}
@@ -0,0 +1,235 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"mr-metrics/internal/model"
+	"time"
+)
+
+// reviewSample is the review of a single merge request.
+type reviewSample struct {
+	projectName string
+	createdAt   time.Time
+	rounds      int
+	events      []model.ReviewEvent
+}
+
+type reviewerAccumulator struct {
+	commented int
+	approved  int
+	responses []time.Duration
+}
+
+// scanReviewSamples collects review events of merge requests, the rows have to be ordered by merge request.
+func scanReviewSamples(rows *sql.Rows) ([]reviewSample, error) {
+	var (
+		samples     []reviewSample
+		lastProject string
+		lastIID     int
+	)
+	for rows.Next() {
+		var (
+			sample         reviewSample
+			iid            int
+			username, kind sql.NullString
+			eventCreatedAt sql.NullTime
+			estimated      sql.NullBool
+		)
+		err := rows.Scan(
+			&sample.projectName, &iid, &sample.createdAt, &sample.rounds, &username, &kind, &eventCreatedAt, &estimated,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		if len(samples) == 0 || sample.projectName != lastProject || iid != lastIID {
+			samples = append(samples, sample)
+			lastProject, lastIID = sample.projectName, iid
+		}
+
+		if username.Valid {
+			last := &samples[len(samples)-1]
+			last.events = append(last.events, model.ReviewEvent{
+				Username:  username.String,
+				Kind:      model.ReviewEventKind(kind.String),
+				CreatedAt: eventCreatedAt.Time,
+				Estimated: estimated.Bool,
+			})
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return samples, nil
+}
+
+func buildReviewStats(samples []reviewSample) *model.ReviewStats {
+	projectSamples := make(map[string][]reviewSample)
+	reviewers := make(map[string]*reviewerAccumulator)
+
+	for _, sample := range samples {
+		projectName := extractProjectName(sample.projectName)
+		projectSamples[projectName] = append(projectSamples[projectName], sample)
+
+		for username, response := range firstResponses(sample) {
+			acc, exists := reviewers[username]
+			if !exists {
+				acc = &reviewerAccumulator{}
+				reviewers[username] = acc
+			}
+			if response.commented {
+				acc.commented++
+			}
+			if response.approved {
+				acc.approved++
+			}
+			if !response.at.IsZero() {
+				acc.responses = append(acc.responses, response.at.Sub(sample.createdAt))
+			}
+		}
+	}
+
+	projects := make(map[string]model.ProjectReviewStats, len(projectSamples))
+	for projectName, samples := range projectSamples {
+		projects[projectName] = buildProjectReviewStats(samples)
+	}
+
+	reviewerStats := make(map[string]model.ReviewerStats, len(reviewers))
+	for username, acc := range reviewers {
+		reviewerStats[username] = model.ReviewerStats{
+			Commented:           acc.commented,
+			Approved:            acc.approved,
+			TimeToFirstResponse: newDurations(acc.responses),
+		}
+	}
+
+	return &model.ReviewStats{
+		Projects:  projects,
+		Reviewers: reviewerStats,
+	}
+}
+
+func buildProjectReviewStats(samples []reviewSample) model.ProjectReviewStats {
+	var toComment, toApproval []time.Duration
+	rounds := 0
+
+	for _, sample := range samples {
+		rounds += sample.rounds
+		if at, ok := firstEvent(sample.events, model.ReviewEventComment); ok {
+			toComment = append(toComment, at.Sub(sample.createdAt))
+		}
+		if at, ok := firstEvent(sample.events, model.ReviewEventApproval); ok {
+			toApproval = append(toApproval, at.Sub(sample.createdAt))
+		}
+	}
+
+	return model.ProjectReviewStats{
+		MergeRequests:       len(samples),
+		TimeToFirstComment:  newDurations(toComment),
+		TimeToFirstApproval: newDurations(toApproval),
+		ReviewRounds:        float64(rounds) / float64(len(samples)),
+	}
+}
+
+// firstEvent returns the time of the first event of the kind, events with estimated time are skipped.
+func firstEvent(events []model.ReviewEvent, kind model.ReviewEventKind) (time.Time, bool) {
+	var first time.Time
+	for _, event := range events {
+		if event.Kind == kind && !event.Estimated && (first.IsZero() || event.CreatedAt.Before(first)) {
+			first = event.CreatedAt
+		}
+	}
+	return first, !first.IsZero()
+}
+
+type reviewerResponse struct {
+	commented bool
+	approved  bool
+	at        time.Time
+}
+
+// firstResponses returns what every reviewer did on the merge request and when they responded first.
+// The time is zero if all events of a reviewer have estimated time.
+func firstResponses(sample reviewSample) map[string]reviewerResponse {
+	responses := make(map[string]reviewerResponse)
+	for _, event := range sample.events {
+		response := responses[event.Username]
+		if !event.Estimated && (response.at.IsZero() || event.CreatedAt.Before(response.at)) {
+			response.at = event.CreatedAt
+		}
+		switch event.Kind {
+		case model.ReviewEventComment:
+			response.commented = true
+		case model.ReviewEventApproval:
+			response.approved = true
+		}
+		responses[event.Username] = response
+	}
+	return responses
+}
+
+// reviewerCountsSample is the amount of reviewed merge requests of a reviewer in a project.
+type reviewerCountsSample struct {
+	username    string
+	projectName string
+	counts      model.ReviewCounts
+}
+
+func scanReviewerCountsSamples(rows *sql.Rows) ([]reviewerCountsSample, error) {
+	var samples []reviewerCountsSample
+	for rows.Next() {
+		var sample reviewerCountsSample
+		err := rows.Scan(&sample.username, &sample.projectName, &sample.counts.Approved, &sample.counts.Commented)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		samples = append(samples, sample)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return samples, nil
+}
+
+func buildReviewerAggregatedStats(samples []reviewerCountsSample) *model.ReviewerAggregatedStats {
+	stats := &model.ReviewerAggregatedStats{
+		Reviewers:      make(map[string]map[string]model.ReviewCounts),
+		ReviewerTotals: make(map[string]model.ReviewCounts),
+		RepoTotals:     make(map[string]model.ReviewCounts),
+	}
+	projectsSet := make(map[string]struct{})
+
+	for _, sample := range samples {
+		projectName := extractProjectName(sample.projectName)
+		projectsSet[projectName] = struct{}{}
+
+		if _, exists := stats.Reviewers[sample.username]; !exists {
+			stats.Reviewers[sample.username] = make(map[string]model.ReviewCounts)
+		}
+		stats.Reviewers[sample.username][projectName] = addReviewCounts(
+			stats.Reviewers[sample.username][projectName], sample.counts,
+		)
+		stats.ReviewerTotals[sample.username] = addReviewCounts(stats.ReviewerTotals[sample.username], sample.counts)
+		stats.RepoTotals[projectName] = addReviewCounts(stats.RepoTotals[projectName], sample.counts)
+		stats.Total = addReviewCounts(stats.Total, sample.counts)
+	}
+
+	stats.Projects = sortedKeys(projectsSet)
+	return stats
+}
+
+func addReviewCounts(a, b model.ReviewCounts) model.ReviewCounts {
+	return model.ReviewCounts{
+		Approved:  a.Approved + b.Approved,
+		Commented: a.Commented + b.Commented,
+	}
+}
@@ -0,0 +1,611 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mr-metrics/internal/model"
+	"time"
+
+	// Blank import registers the pure Go SQLite driver.
+	_ "modernc.org/sqlite"
+)
+
+// sqliteOptions enables foreign keys for cascade deletes, waits for locks instead of failing
+// and stores times in a format that is compared correctly as text.
+const sqliteOptions = "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)" +
+	"&_txlock=immediate&_time_format=sqlite"
+
+// SQLiteStore keeps the data in a single file, so mr-metrics can run without a database server.
+// NOTE(danilax86): Unlike PostgresStore it doesn't keep daily cumulative counts,
+// the counts are derived from merge requests on every query.
+type SQLiteStore struct {
+	db *sql.DB
+	// excludeReverts excludes merge requests which titles start with "Revert" from the counts
+	excludeReverts bool
+}
+
+// NewSQLiteStore opens the database file, creating it if needed, and applies the migrations if autoMigrate is set,
+// otherwise it only checks that they were applied by the migrate command.
+func NewSQLiteStore(filePath string, excludeReverts, autoMigrate bool) (*SQLiteStore, error) {
+	db, err := openSQLite(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := prepareSchema(db, dialectSQLite, autoMigrate); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteStore{db: db, excludeReverts: excludeReverts}, nil
+}
+
+func openSQLite(filePath string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", "file:"+filePath+"?"+sqliteOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	return db, nil
+}
+
+// Close closes the database connections.
+func (s SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// GetLastUpdatedDate returns when the project was updated last time, sql.ErrNoRows if it was never stored
+// or was stored with older fields, so it's fetched from scratch.
+func (s SQLiteStore) GetLastUpdatedDate(ctx context.Context, projectName string) (time.Time, error) {
+	var lastUpdated time.Time
+	err := s.db.QueryRowContext(ctx, `
+		SELECT last_updated
+		FROM projects
+		WHERE project_name = $1 AND history_version >= $2
+	`, projectName, historyVersion).Scan(&lastUpdated)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return lastUpdated, nil
+}
+
+func (s SQLiteStore) UpdateProjectCache(ctx context.Context, gitlabID int, projectName string, mrs []model.MergeRequest) error {
+	return s.updateProjectCache(ctx, gitlabID, projectName, mrs, nil)
+}
+
+// ReplaceProjectCache replaces the stored merge requests of a project merged since the given time
+// (all of them if it is zero) with the given ones, so the merge requests that are no longer returned
+// by GitLab are removed.
+func (s SQLiteStore) ReplaceProjectCache(
+	ctx context.Context, gitlabID int, projectName string, since time.Time, mrs []model.MergeRequest,
+) error {
+	return s.updateProjectCache(ctx, gitlabID, projectName, mrs, &since)
+}
+
+func (s SQLiteStore) updateProjectCache(
+	ctx context.Context, gitlabID int, projectName string, mrs []model.MergeRequest, replaceSince *time.Time,
+) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if replaceSince != nil {
+		_, err = tx.ExecContext(ctx, `
+			DELETE FROM merge_requests
+			WHERE project_id IN (SELECT project_id FROM projects WHERE project_name = $1)
+			AND merged_at >= $2
+		`, projectName, replaceSince.UTC())
+		if err != nil {
+			return fmt.Errorf("failed to remove merge requests: %w", err)
+		}
+	}
+
+	var projectID int
+	if gitlabID == 0 {
+		// The GitLab ID is unknown, so only an already stored project is updated
+		err = tx.QueryRowContext(ctx, `
+			UPDATE projects
+			SET last_updated = $1
+			WHERE project_name = $2
+			RETURNING project_id
+		`, time.Now().UTC(), projectName).Scan(&projectID)
+		if errors.Is(err, sql.ErrNoRows) {
+			// The project was never stored
+			return nil
+		}
+	} else {
+		// NOTE(danilax86): project_id is generated on insert, the same GitLab IDs of different sources don't collide.
+		source, _ := model.SplitProjectName(projectName)
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO projects (source, gitlab_id, project_name, last_updated)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (source, gitlab_id) DO UPDATE SET
+				project_name = excluded.project_name,
+				last_updated = excluded.last_updated
+			RETURNING project_id
+		`, source, gitlabID, projectName, time.Now().UTC()).Scan(&projectID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update project: %w", err)
+	}
+
+	// NOTE(danilax86): Projects stored with older fields have no last update date, so they are fetched
+	// from scratch, only a partial rebuild doesn't store their whole history.
+	if replaceSince == nil || replaceSince.IsZero() {
+		if err := setHistoryVersion(ctx, tx, projectID); err != nil {
+			return err
+		}
+	}
+
+	for _, mr := range mrs {
+		if err := upsertSQLiteMergeRequest(ctx, tx, projectID, mr); err != nil {
+			return fmt.Errorf("failed to store merge requests: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// upsertSQLiteMergeRequest inserts the merge request with its review events or updates them if they were already stored.
+func upsertSQLiteMergeRequest(ctx context.Context, tx *sql.Tx, projectID int, mr model.MergeRequest) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO merge_requests (
+			project_id, iid, title, username, source_branch, target_branch, labels, web_url, created_at, merged_at,
+			review_rounds, changes_count
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (project_id, iid) DO UPDATE SET
+			title = excluded.title,
+			username = excluded.username,
+			source_branch = excluded.source_branch,
+			target_branch = excluded.target_branch,
+			labels = excluded.labels,
+			web_url = excluded.web_url,
+			created_at = excluded.created_at,
+			merged_at = excluded.merged_at,
+			review_rounds = excluded.review_rounds,
+			changes_count = excluded.changes_count
+	`, projectID, mr.IID, mr.Title, mr.Username, mr.SourceBranch, mr.TargetBranch,
+		jsonArray(mr.Labels), mr.WebURL, mr.CreatedAt.UTC(), mr.MergedAt.UTC(), mr.Review.Rounds, mr.ChangesCount)
+	if err != nil {
+		return fmt.Errorf("failed to upsert merge request !%d: %w", mr.IID, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM review_events
+		WHERE project_id = $1 AND mr_iid = $2
+	`, projectID, mr.IID)
+	if err != nil {
+		return fmt.Errorf("failed to delete review events: %w", err)
+	}
+
+	for _, event := range mr.Review.Events {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO review_events (project_id, mr_iid, username, kind, created_at, estimated)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, projectID, mr.IID, event.Username, event.Kind, event.CreatedAt.UTC(), event.Estimated)
+		if err != nil {
+			return fmt.Errorf("failed to add review event: %w", err)
+		}
+	}
+	return nil
+}
+
+// SetTrackedProjects marks the given projects as tracked and all the others as not tracked,
+// so the stats of projects that are no longer updated are hidden.
+func (s SQLiteStore) SetTrackedProjects(ctx context.Context, projectNames []string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE projects
+		SET tracked = project_name IN (SELECT value FROM json_each($1))
+	`, jsonArray(projectNames))
+	if err != nil {
+		return fmt.Errorf("failed to update tracked projects: %w", err)
+	}
+	return nil
+}
+
+// DeleteUsers removes the merge requests and the review events of the users.
+func (s SQLiteStore) DeleteUsers(ctx context.Context, usernames []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	users := jsonArray(usernames)
+	_, err = tx.ExecContext(ctx, `DELETE FROM review_events WHERE username IN (SELECT value FROM json_each($1))`, users)
+	if err != nil {
+		return fmt.Errorf("failed to delete review events: %w", err)
+	}
+
+	// NOTE(danilax86): Review events of the merge requests are removed together with them.
+	_, err = tx.ExecContext(ctx, `DELETE FROM merge_requests WHERE username IN (SELECT value FROM json_each($1))`, users)
+	if err != nil {
+		return fmt.Errorf("failed to delete merge requests: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// GetTrackedProjects returns names of the projects which stats are shown.
+func (s SQLiteStore) GetTrackedProjects(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT project_name
+		FROM projects
+		WHERE tracked
+		ORDER BY project_name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	return scanStrings(rows)
+}
+
+func (s SQLiteStore) GetAggregatedDataForDate(ctx context.Context, projectNames []string, targetDate time.Time) (*model.AggregatedStats, error) {
+	return s.GetAggregatedDataForRange(ctx, projectNames, time.Time{}, targetDate)
+}
+
+// GetAggregatedDataForRange returns the amount of merged requests per developer and project
+// that were merged between from (inclusive) and to (inclusive).
+func (s SQLiteStore) GetAggregatedDataForRange(ctx context.Context, projectNames []string, from, to time.Time) (*model.AggregatedStats, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		WITH latest_data AS (
+			SELECT mr.username, p.project_id, p.project_name, COUNT(*) AS merge_count
+			FROM merge_requests mr
+			JOIN projects p ON mr.project_id = p.project_id
+			WHERE p.project_name IN (SELECT value FROM json_each($1))
+			AND mr.merged_at BETWEEN $2 AND $3
+			AND NOT (mr.is_revert AND $4)
+			GROUP BY mr.username, p.project_id, p.project_name
+		),`+getAggregatedTotalsSQL(), jsonArray(projectNames), from.UTC(), to.UTC(), s.excludeReverts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	return scanAggregatedData(rows)
+}
+
+// GetLeadTimes returns the distribution of time between creating and merging merge requests
+// that were merged between from (inclusive) and to (inclusive).
+func (s SQLiteStore) GetLeadTimes(ctx context.Context, projectNames []string, from, to time.Time) (*model.LeadTimeStats, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT mr.username, p.project_name, mr.created_at, mr.merged_at
+		FROM merge_requests mr
+		JOIN projects p ON mr.project_id = p.project_id
+		WHERE p.project_name IN (SELECT value FROM json_each($1))
+		AND mr.merged_at BETWEEN $2 AND $3
+	`, jsonArray(projectNames), from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	samples, err := scanLeadTimeSamples(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildLeadTimeStats(samples), nil
+}
+
+// GetReviewStats returns how fast merge requests merged between from (inclusive) and to (inclusive) were reviewed.
+func (s SQLiteStore) GetReviewStats(ctx context.Context, projectNames []string, from, to time.Time) (*model.ReviewStats, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT p.project_name, mr.iid, mr.created_at, mr.review_rounds, e.username, e.kind, e.created_at, e.estimated
+		FROM merge_requests mr
+		JOIN projects p ON mr.project_id = p.project_id
+		LEFT JOIN review_events e ON e.project_id = mr.project_id AND e.mr_iid = mr.iid
+		WHERE p.project_name IN (SELECT value FROM json_each($1))
+		AND mr.merged_at BETWEEN $2 AND $3
+		ORDER BY p.project_name, mr.iid, e.created_at
+	`, jsonArray(projectNames), from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	samples, err := scanReviewSamples(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildReviewStats(samples), nil
+}
+
+// GetReviewerDataForDate returns the amount of merge requests every reviewer approved and commented on
+// per project up to the target date.
+func (s SQLiteStore) GetReviewerDataForDate(
+	ctx context.Context, projectNames []string, targetDate time.Time,
+) (*model.ReviewerAggregatedStats, error) {
+	return s.GetReviewerDataForRange(ctx, projectNames, time.Time{}, targetDate)
+}
+
+// GetReviewerDataForRange returns the amount of merge requests every reviewer approved and commented on
+// per project between from (inclusive) and to (inclusive).
+func (s SQLiteStore) GetReviewerDataForRange(
+	ctx context.Context, projectNames []string, from, to time.Time,
+) (*model.ReviewerAggregatedStats, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT
+			e.username,
+			p.project_name,
+			COUNT(DISTINCT e.mr_iid) FILTER (WHERE e.kind = 'approval'),
+			COUNT(DISTINCT e.mr_iid) FILTER (WHERE e.kind = 'comment')
+		FROM review_events e
+		JOIN projects p ON e.project_id = p.project_id
+		WHERE p.project_name IN (SELECT value FROM json_each($1))
+		AND e.created_at BETWEEN $2 AND $3
+		GROUP BY e.username, p.project_name
+	`, jsonArray(projectNames), from.UTC(), to.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	samples, err := scanReviewerCountsSamples(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildReviewerAggregatedStats(samples), nil
+}
+
+// GetDeveloperStats returns the merged requests of a developer between from (inclusive) and to (inclusive)
+// split into weeks together with the largest and the most recent ones.
+func (s SQLiteStore) GetDeveloperStats(
+	ctx context.Context, username string, projectNames []string, from, to time.Time,
+) (*model.DeveloperStats, error) {
+	projects := jsonArray(projectNames)
+	from, to = from.UTC(), to.UTC()
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT p.project_name, mr.username, mr.merged_at
+		FROM merge_requests mr
+		JOIN projects p ON mr.project_id = p.project_id
+		WHERE mr.username = $1
+		AND p.project_name IN (SELECT value FROM json_each($2))
+		AND mr.merged_at BETWEEN $3 AND $4
+		AND NOT (mr.is_revert AND $5)
+	`, username, projects, from, to, s.excludeReverts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	samples, err := scanMergeSamples(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	var previousTotal int
+	err = s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM merge_requests mr
+		JOIN projects p ON mr.project_id = p.project_id
+		WHERE mr.username = $1
+		AND p.project_name IN (SELECT value FROM json_each($2))
+		AND mr.merged_at >= $3 AND mr.merged_at < $4
+		AND NOT (mr.is_revert AND $5)
+	`, username, projects, from.Add(-to.Sub(from)), from, s.excludeReverts).Scan(&previousTotal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count previous merge requests: %w", err)
+	}
+
+	largest, err := s.getMergeRequestSummaries(ctx, `
+		WHERE mr.username = $1 AND p.project_name IN (SELECT value FROM json_each($2)) AND mr.merged_at BETWEEN $3 AND $4
+		AND NOT (mr.is_revert AND $5)
+		ORDER BY mr.changes_count DESC, mr.merged_at DESC
+		LIMIT $6
+	`, username, projects, from, to, s.excludeReverts, mrListLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	recent, err := s.getMergeRequestSummaries(ctx, `
+		WHERE mr.username = $1 AND p.project_name IN (SELECT value FROM json_each($2))
+		AND NOT (mr.is_revert AND $3)
+		ORDER BY mr.merged_at DESC
+		LIMIT $4
+	`, username, projects, s.excludeReverts, mrListLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.DeveloperStats{
+		Username:      username,
+		Weekly:        buildWeeklyMerges(from, to, samples),
+		Total:         len(samples),
+		PreviousTotal: previousTotal,
+		LargestMRs:    largest,
+		RecentMRs:     recent,
+	}, nil
+}
+
+// GetProjectStats returns the merged requests of a project between from (inclusive) and to (inclusive)
+// split into weeks and contributors together with the latest sync runs of the project.
+func (s SQLiteStore) GetProjectStats(ctx context.Context, projectID int, from, to time.Time) (*model.ProjectStats, error) {
+	stats := model.ProjectStats{ProjectID: projectID}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT project_name, last_updated
+		FROM projects
+		WHERE project_id = $1
+	`, projectID).Scan(&stats.ProjectName, &stats.LastUpdated)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT p.project_name, mr.username, mr.merged_at
+		FROM merge_requests mr
+		JOIN projects p ON mr.project_id = p.project_id
+		WHERE mr.project_id = $1
+		AND mr.merged_at BETWEEN $2 AND $3
+		AND NOT (mr.is_revert AND $4)
+	`, projectID, from.UTC(), to.UTC(), s.excludeReverts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	samples, err := scanMergeSamples(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	stats.SyncRuns, err = s.GetSyncRuns(ctx, stats.ProjectName, syncRunsLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	stats.Weekly = buildWeeklyMerges(from, to, samples)
+	stats.Total = len(samples)
+	stats.Contributors, stats.BusFactor = buildContributors(samples)
+	return &stats, nil
+}
+
+// AddSyncRun stores an attempt to update a project.
+func (s SQLiteStore) AddSyncRun(ctx context.Context, run model.SyncRun) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO sync_runs (project_name, started_at, finished_at, since, merge_requests, error)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, run.ProjectName, run.StartedAt.UTC(), run.FinishedAt.UTC(),
+		sql.NullTime{Time: run.Since.UTC(), Valid: !run.Since.IsZero()},
+		run.MergeRequests,
+		sql.NullString{String: run.Error, Valid: run.Error != ""},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add sync run: %w", err)
+	}
+	return nil
+}
+
+// AddSyncRequest stores a requested sync for the leader unless the given amount of requests is waiting already.
+// It reports whether the request was stored.
+func (s SQLiteStore) AddSyncRequest(ctx context.Context, request model.SyncRequest, limit int) (bool, error) {
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO sync_requests (project_name, since, rebuild)
+		SELECT $1, $2, $3
+		WHERE (SELECT COUNT(*) FROM sync_requests) < $4
+	`, request.ProjectName, sql.NullTime{Time: request.Since.UTC(), Valid: !request.Since.IsZero()}, request.Rebuild, limit)
+	if err != nil {
+		return false, fmt.Errorf("failed to add sync request: %w", err)
+	}
+
+	added, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to add sync request: %w", err)
+	}
+	return added > 0, nil
+}
+
+// TakeSyncRequest removes the oldest requested sync and returns it, false if there are none.
+func (s SQLiteStore) TakeSyncRequest(ctx context.Context) (model.SyncRequest, bool, error) {
+	return scanSyncRequest(s.db.QueryRowContext(ctx, `
+		DELETE FROM sync_requests
+		WHERE id = (SELECT MIN(id) FROM sync_requests)
+		RETURNING project_name, since, rebuild
+	`))
+}
+
+// GetSyncRuns returns the latest sync runs of a project or of all projects if the project name is empty.
+func (s SQLiteStore) GetSyncRuns(ctx context.Context, projectName string, limit int) ([]model.SyncRun, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT project_name, started_at, finished_at, since, merge_requests, error
+		FROM sync_runs
+		WHERE $1 = '' OR project_name = $1
+		ORDER BY started_at DESC
+		LIMIT $2
+	`, projectName, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	return scanSyncRuns(rows)
+}
+
+// GetSyncStatus returns the last update time and the latest sync run of every tracked project
+// and of the projects that were never synced successfully.
+func (s SQLiteStore) GetSyncStatus(ctx context.Context) ([]model.ProjectSyncStatus, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT project_id, project_name, last_updated, tracked
+		FROM projects
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	projects, err := scanSyncedProjects(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	runRows, err := s.db.QueryContext(ctx, `
+		SELECT r.project_name, r.started_at, r.finished_at, r.since, r.merge_requests, r.error
+		FROM sync_runs r
+		WHERE r.id = (
+			SELECT latest.id
+			FROM sync_runs latest
+			WHERE latest.project_name = r.project_name
+			ORDER BY latest.started_at DESC
+			LIMIT 1
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer runRows.Close()
+
+	latestRuns, err := scanSyncRuns(runRows)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildSyncStatus(projects, latestRuns), nil
+}
+
+// getMergeRequestSummaries returns merge requests selected by the condition and the order of the query tail.
+func (s SQLiteStore) getMergeRequestSummaries(ctx context.Context, tail string, args ...any) ([]model.MergeRequestSummary, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT p.project_name, mr.iid, mr.title, mr.web_url, mr.changes_count, mr.merged_at, mr.is_revert
+		FROM merge_requests mr
+		JOIN projects p ON mr.project_id = p.project_id
+	`+tail, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer rows.Close()
+
+	return scanMergeRequestSummaries(rows)
+}
+
+// jsonArray encodes values as a JSON array, SQLite has no arrays, so lists are passed to json_each.
+func jsonArray(values []string) string {
+	if values == nil {
+		values = []string{}
+	}
+
+	encoded, _ := json.Marshal(values) //nolint:errchkjson // encoding strings can't fail
+	return string(encoded)
+}
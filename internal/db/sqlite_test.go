@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package db_test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"mr-metrics/internal/db"
+)
+
+// openSQLite opens a store in a new database file and returns it with the path of the file.
+func openSQLite(t *testing.T, excludeReverts bool) (db.Store, string) {
+	t.Helper()
+
+	filePath := filepath.Join(t.TempDir(), "mr-metrics.db")
+	store, err := db.Open("sqlite://"+filePath, excludeReverts, true)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store, filePath
+}
+
+func TestSQLiteStore(t *testing.T) {
+	t.Parallel()
+
+	testStore(t, func(t *testing.T, excludeReverts bool) db.Store {
+		t.Helper()
+		store, _ := openSQLite(t, excludeReverts)
+		return store
+	})
+}
+
+func TestSQLiteStoreHistoryVersion(t *testing.T) {
+	t.Parallel()
+
+	store, filePath := openSQLite(t, false)
+	fillStore(t, store)
+
+	conn, err := sql.Open("sqlite", "file:"+filePath)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	testHistoryVersion(t, store, conn)
+}
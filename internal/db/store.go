@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"mr-metrics/internal/model"
+	"strings"
+	"time"
+)
+
+// historyVersion is the version of the merge request fields fetched from GitLab. Projects stored
+// with an older version are fetched from scratch once, so a migration adding such a field bumps it
+// instead of resetting last_updated of every project.
+const historyVersion = 1
+
+// Store keeps the merge requests synced from GitLab and builds the stats shown by the handlers.
+type Store interface {
+	GetLastUpdatedDate(ctx context.Context, projectName string) (time.Time, error)
+	// UpdateProjectCache and ReplaceProjectCache take the ID of the project in its GitLab source
+	// and the qualified name of the project, the source is taken from the name.
+	UpdateProjectCache(ctx context.Context, gitlabID int, projectName string, mrs []model.MergeRequest) error
+	ReplaceProjectCache(ctx context.Context, gitlabID int, projectName string, since time.Time, mrs []model.MergeRequest) error
+	SetTrackedProjects(ctx context.Context, projectNames []string) error
+	// DeleteUsers removes the merge requests and the review events of the users, e.g. after they were excluded.
+	DeleteUsers(ctx context.Context, usernames []string) error
+	AddSyncRun(ctx context.Context, run model.SyncRun) error
+
+	GetTrackedProjects(ctx context.Context) ([]string, error)
+	GetAggregatedDataForDate(ctx context.Context, projectNames []string, targetDate time.Time) (*model.AggregatedStats, error)
+	GetAggregatedDataForRange(ctx context.Context, projectNames []string, from, to time.Time) (*model.AggregatedStats, error)
+	GetLeadTimes(ctx context.Context, projectNames []string, from, to time.Time) (*model.LeadTimeStats, error)
+	GetReviewStats(ctx context.Context, projectNames []string, from, to time.Time) (*model.ReviewStats, error)
+	GetReviewerDataForDate(ctx context.Context, projectNames []string, targetDate time.Time) (*model.ReviewerAggregatedStats, error)
+	GetReviewerDataForRange(ctx context.Context, projectNames []string, from, to time.Time) (*model.ReviewerAggregatedStats, error)
+	GetDeveloperStats(ctx context.Context, username string, projectNames []string, from, to time.Time) (*model.DeveloperStats, error)
+	GetProjectStats(ctx context.Context, projectID int, from, to time.Time) (*model.ProjectStats, error)
+	GetSyncRuns(ctx context.Context, projectName string, limit int) ([]model.SyncRun, error)
+	GetSyncStatus(ctx context.Context) ([]model.ProjectSyncStatus, error)
+
+	Close() error
+}
+
+// Open connects to the database chosen by the scheme of the URL: sqlite:// followed by a file path for SQLite,
+// postgres:// or postgresql:// for Postgres. URLs without a scheme are Postgres connection strings.
+// Migrations are applied if autoMigrate is set, otherwise the store fails to open if they weren't applied.
+func Open(databaseURL string, excludeReverts, autoMigrate bool) (Store, error) {
+	dialect, dsn, err := parseDatabaseURL(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if dialect == dialectSQLite {
+		return NewSQLiteStore(dsn, excludeReverts, autoMigrate)
+	}
+	return NewPostgresStore(dsn, excludeReverts, autoMigrate)
+}
+
+// parseDatabaseURL returns the database chosen by the scheme of the URL and the connection string of the driver.
+func parseDatabaseURL(databaseURL string) (string, string, error) {
+	scheme, rest, found := strings.Cut(databaseURL, "://")
+	switch {
+	case !found, scheme == "postgres", scheme == "postgresql":
+		return dialectPostgres, databaseURL, nil
+	case scheme == "sqlite":
+		return dialectSQLite, rest, nil
+	default:
+		return "", "", fmt.Errorf("unsupported database URL scheme %q", scheme)
+	}
+}
+
+func openDatabase(dialect, dsn string) (*sql.DB, error) {
+	if dialect == dialectSQLite {
+		return openSQLite(dsn)
+	}
+	return openPostgres(dsn)
+}
+
+// setHistoryVersion marks the project as stored with the current fields, so it isn't fetched from scratch again.
+func setHistoryVersion(ctx context.Context, tx *sql.Tx, projectID int) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE projects
+		SET history_version = $1
+		WHERE project_id = $2
+	`, historyVersion, projectID)
+	if err != nil {
+		return fmt.Errorf("failed to update history version: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,672 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package db_test
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"mr-metrics/internal/db"
+	"mr-metrics/internal/model"
+	"mr-metrics/internal/service/updater"
+)
+
+const (
+	webProject = "group/web"
+	apiProject = "group/api"
+
+	webID = 1
+	apiID = 2
+
+	alice = "alice"
+	bob   = "bob"
+	carol = "carol"
+	dave  = "dave"
+)
+
+// openStore opens an empty store, merge requests reverting others aren't counted if excludeReverts is set.
+type openStore func(t *testing.T, excludeReverts bool) db.Store
+
+// base is the time every merge request of the tests is created or merged around, it's a Monday.
+func base() time.Time {
+	return time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC)
+}
+
+func newMergeRequest(iid int, username, title string, createdAt, mergedAt time.Time) model.MergeRequest {
+	return model.MergeRequest{
+		IID:          iid,
+		Title:        title,
+		Username:     username,
+		SourceBranch: "feature",
+		TargetBranch: "main",
+		Labels:       []string{"backend"},
+		WebURL:       "https://gitlab.example.com/mr",
+		ChangesCount: iid,
+		CreatedAt:    createdAt,
+		MergedAt:     mergedAt,
+	}
+}
+
+func webMergeRequests() []model.MergeRequest {
+	return []model.MergeRequest{
+		newMergeRequest(1, alice, "Add login", base().Add(-2*time.Hour), base()),
+		newMergeRequest(2, alice, "Add logout", base().Add(24*time.Hour), base().Add(26*time.Hour)),
+		newMergeRequest(3, bob, `Revert "Add login"`, base().Add(48*time.Hour), base().Add(50*time.Hour)),
+	}
+}
+
+func apiMergeRequests() []model.MergeRequest {
+	return []model.MergeRequest{
+		newMergeRequest(1, bob, "Add users endpoint", base().Add(20*time.Hour), base().Add(24*time.Hour)),
+	}
+}
+
+// fillStore stores and tracks the merge requests of both projects.
+func fillStore(t *testing.T, store db.Store) {
+	t.Helper()
+
+	if err := store.UpdateProjectCache(t.Context(), webID, webProject, webMergeRequests()); err != nil {
+		t.Fatalf("failed to update %s: %v", webProject, err)
+	}
+	if err := store.UpdateProjectCache(t.Context(), apiID, apiProject, apiMergeRequests()); err != nil {
+		t.Fatalf("failed to update %s: %v", apiProject, err)
+	}
+	if err := store.SetTrackedProjects(t.Context(), []string{webProject, apiProject}); err != nil {
+		t.Fatalf("failed to set tracked projects: %v", err)
+	}
+}
+
+func getAggregatedData(t *testing.T, store db.Store, from, to time.Time) *model.AggregatedStats {
+	t.Helper()
+
+	stats, err := store.GetAggregatedDataForRange(t.Context(), []string{webProject, apiProject}, from, to)
+	if err != nil {
+		t.Fatalf("GetAggregatedDataForRange failed: %v", err)
+	}
+	return stats
+}
+
+func assertDevelopers(t *testing.T, stats *model.AggregatedStats, want map[string]map[string]int) {
+	t.Helper()
+
+	if len(stats.Developers) == 0 && len(want) == 0 {
+		return
+	}
+	if !reflect.DeepEqual(stats.Developers, want) {
+		t.Errorf("Developers = %v, want %v", stats.Developers, want)
+	}
+}
+
+// testStore runs the tests every Store has to pass, each of them on a new store.
+func testStore(t *testing.T, open openStore) {
+	t.Helper()
+
+	tests := []struct {
+		name string
+		test func(t *testing.T, open openStore)
+	}{
+		{"LastUpdatedDate", testLastUpdatedDate},
+		{"UpdateWithoutGitLabID", testUpdateWithoutGitLabID},
+		{"RenamedProject", testRenamedProject},
+		{"TrackedProjects", testTrackedProjects},
+		{"AggregatedData", testAggregatedData},
+		{"ExcludeReverts", testExcludeReverts},
+		{"ReplaceProjectCache", testReplaceProjectCache},
+		{"ReviewStats", testReviewStats},
+		{"ReviewerData", testReviewerData},
+		{"DeleteUsers", testDeleteUsers},
+		{"DeveloperStats", testDeveloperStats},
+		{"ProjectStats", testProjectStats},
+		{"SyncRuns", testSyncRuns},
+		{"SyncStatus", testSyncStatus},
+		{"SyncRequests", testSyncRequests},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tt.test(t, open)
+		})
+	}
+}
+
+func testLastUpdatedDate(t *testing.T, open openStore) {
+	t.Helper()
+	store := open(t, false)
+
+	if _, err := store.GetLastUpdatedDate(t.Context(), webProject); err == nil {
+		t.Error("GetLastUpdatedDate of a project that was never stored succeeded")
+	}
+
+	before := time.Now().Add(-time.Second)
+	fillStore(t, store)
+
+	lastUpdated, err := store.GetLastUpdatedDate(t.Context(), webProject)
+	if err != nil {
+		t.Fatalf("GetLastUpdatedDate failed: %v", err)
+	}
+	if lastUpdated.Before(before) || lastUpdated.After(time.Now().Add(time.Second)) {
+		t.Errorf("GetLastUpdatedDate = %v, want about %v", lastUpdated, time.Now())
+	}
+}
+
+func testUpdateWithoutGitLabID(t *testing.T, open openStore) {
+	t.Helper()
+	store := open(t, false)
+
+	// A project which GitLab ID is unknown isn't stored
+	if err := store.UpdateProjectCache(t.Context(), 0, webProject, webMergeRequests()); err != nil {
+		t.Fatalf("UpdateProjectCache failed: %v", err)
+	}
+	if _, err := store.GetLastUpdatedDate(t.Context(), webProject); err == nil {
+		t.Error("project without a GitLab ID was stored")
+	}
+
+	// But a stored one is updated by its name
+	if err := store.UpdateProjectCache(t.Context(), webID, webProject, webMergeRequests()[:1]); err != nil {
+		t.Fatalf("UpdateProjectCache failed: %v", err)
+	}
+	if err := store.UpdateProjectCache(t.Context(), 0, webProject, webMergeRequests()[1:2]); err != nil {
+		t.Fatalf("UpdateProjectCache failed: %v", err)
+	}
+	if err := store.SetTrackedProjects(t.Context(), []string{webProject}); err != nil {
+		t.Fatalf("SetTrackedProjects failed: %v", err)
+	}
+
+	stats := getAggregatedData(t, store, time.Time{}, base().Add(365*24*time.Hour))
+	assertDevelopers(t, stats, map[string]map[string]int{alice: {"web": 2}})
+}
+
+func testRenamedProject(t *testing.T, open openStore) {
+	t.Helper()
+	store := open(t, false)
+
+	if err := store.UpdateProjectCache(t.Context(), webID, webProject, webMergeRequests()[:2]); err != nil {
+		t.Fatalf("UpdateProjectCache failed: %v", err)
+	}
+	// The same GitLab project under a new path
+	const renamed = "group/site"
+	if err := store.UpdateProjectCache(t.Context(), webID, renamed, webMergeRequests()[2:]); err != nil {
+		t.Fatalf("UpdateProjectCache failed: %v", err)
+	}
+	if err := store.SetTrackedProjects(t.Context(), []string{renamed}); err != nil {
+		t.Fatalf("SetTrackedProjects failed: %v", err)
+	}
+
+	tracked, err := store.GetTrackedProjects(t.Context())
+	if err != nil {
+		t.Fatalf("GetTrackedProjects failed: %v", err)
+	}
+	if !reflect.DeepEqual(tracked, []string{renamed}) {
+		t.Errorf("GetTrackedProjects = %v, want %v", tracked, []string{renamed})
+	}
+
+	stats, err := store.GetAggregatedDataForDate(t.Context(), []string{renamed}, base().Add(365*24*time.Hour))
+	if err != nil {
+		t.Fatalf("GetAggregatedDataForDate failed: %v", err)
+	}
+	assertDevelopers(t, stats, map[string]map[string]int{alice: {"site": 2}, bob: {"site": 1}})
+}
+
+func testTrackedProjects(t *testing.T, open openStore) {
+	t.Helper()
+	store := open(t, false)
+	fillStore(t, store)
+
+	if err := store.SetTrackedProjects(t.Context(), []string{apiProject, "group/unknown"}); err != nil {
+		t.Fatalf("SetTrackedProjects failed: %v", err)
+	}
+
+	tracked, err := store.GetTrackedProjects(t.Context())
+	if err != nil {
+		t.Fatalf("GetTrackedProjects failed: %v", err)
+	}
+	if !reflect.DeepEqual(tracked, []string{apiProject}) {
+		t.Errorf("GetTrackedProjects = %v, want %v", tracked, []string{apiProject})
+	}
+}
+
+func testAggregatedData(t *testing.T, open openStore) {
+	t.Helper()
+	store := open(t, false)
+	fillStore(t, store)
+
+	// Both bounds are inclusive
+	stats := getAggregatedData(t, store, time.Time{}, base().Add(26*time.Hour))
+	assertDevelopers(t, stats, map[string]map[string]int{alice: {"web": 2}, bob: {"api": 1}})
+	if want := []string{"api", "web"}; !reflect.DeepEqual(stats.Projects, want) {
+		t.Errorf("Projects = %v, want %v", stats.Projects, want)
+	}
+	if want := map[string]int{alice: 2, bob: 1}; !reflect.DeepEqual(stats.DevTotals, want) {
+		t.Errorf("DevTotals = %v, want %v", stats.DevTotals, want)
+	}
+	if want := map[string]int{"web": 2, "api": 1}; !reflect.DeepEqual(stats.RepoTotals, want) {
+		t.Errorf("RepoTotals = %v, want %v", stats.RepoTotals, want)
+	}
+	if stats.ProjectIDs["web"] == 0 || stats.ProjectIDs["api"] == 0 || stats.ProjectIDs["web"] == stats.ProjectIDs["api"] {
+		t.Errorf("ProjectIDs = %v, want distinct IDs of both projects", stats.ProjectIDs)
+	}
+
+	stats = getAggregatedData(t, store, base().Add(26*time.Hour), base().Add(50*time.Hour))
+	assertDevelopers(t, stats, map[string]map[string]int{alice: {"web": 1}, bob: {"web": 1}})
+
+	// Only the requested projects are counted
+	stats, err := store.GetAggregatedDataForDate(t.Context(), []string{apiProject}, base().Add(50*time.Hour))
+	if err != nil {
+		t.Fatalf("GetAggregatedDataForDate failed: %v", err)
+	}
+	assertDevelopers(t, stats, map[string]map[string]int{bob: {"api": 1}})
+}
+
+func testExcludeReverts(t *testing.T, open openStore) {
+	t.Helper()
+	store := open(t, true)
+	fillStore(t, store)
+
+	stats := getAggregatedData(t, store, time.Time{}, base().Add(50*time.Hour))
+	assertDevelopers(t, stats, map[string]map[string]int{alice: {"web": 2}, bob: {"api": 1}})
+	if want := map[string]int{"web": 2, "api": 1}; !reflect.DeepEqual(stats.RepoTotals, want) {
+		t.Errorf("RepoTotals = %v, want %v", stats.RepoTotals, want)
+	}
+
+	// The revert of bob isn't listed on their page either
+	developer, err := store.GetDeveloperStats(t.Context(), bob, []string{webProject, apiProject}, base(), base().Add(50*time.Hour))
+	if err != nil {
+		t.Fatalf("GetDeveloperStats failed: %v", err)
+	}
+	if developer.Total != 1 {
+		t.Errorf("Total = %d, want 1", developer.Total)
+	}
+	for name, mrs := range map[string][]model.MergeRequestSummary{"LargestMRs": developer.LargestMRs, "RecentMRs": developer.RecentMRs} {
+		if len(mrs) != 1 || mrs[0].ProjectName != "api" {
+			t.Errorf("%s = %+v, want the merge request !1 of api", name, mrs)
+		}
+	}
+}
+
+func testReplaceProjectCache(t *testing.T, open openStore) {
+	t.Helper()
+	store := open(t, false)
+	fillStore(t, store)
+	to := base().Add(365 * 24 * time.Hour)
+
+	// Merge requests merged before the given time are kept, the later ones are replaced
+	mrs := webMergeRequests()
+	if err := store.ReplaceProjectCache(t.Context(), webID, webProject, base().Add(time.Hour), mrs[1:2]); err != nil {
+		t.Fatalf("ReplaceProjectCache failed: %v", err)
+	}
+	assertDevelopers(t, getAggregatedData(t, store, time.Time{}, to),
+		map[string]map[string]int{alice: {"web": 2}, bob: {"api": 1}})
+
+	// All of them are replaced without the time
+	if err := store.ReplaceProjectCache(t.Context(), webID, webProject, time.Time{}, mrs[2:]); err != nil {
+		t.Fatalf("ReplaceProjectCache failed: %v", err)
+	}
+	assertDevelopers(t, getAggregatedData(t, store, time.Time{}, to),
+		map[string]map[string]int{bob: {"web": 1, "api": 1}})
+}
+
+// reviewedMergeRequests are merge requests of the web project with comments and approvals,
+// only the approvals of dave have no note, so the time they were given is unknown.
+func reviewedMergeRequests() []model.MergeRequest {
+	mrs := webMergeRequests()[:2]
+	mrs[0].Review = model.MergeRequestReview{
+		Rounds: 2,
+		Events: []model.ReviewEvent{
+			{Username: carol, Kind: model.ReviewEventComment, CreatedAt: base().Add(-time.Hour)},
+			{Username: carol, Kind: model.ReviewEventComment, CreatedAt: base().Add(-45 * time.Minute)},
+			{Username: carol, Kind: model.ReviewEventApproval, CreatedAt: base().Add(-30 * time.Minute)},
+			{Username: dave, Kind: model.ReviewEventApproval, CreatedAt: base(), Estimated: true},
+		},
+	}
+	mrs[1].Review = model.MergeRequestReview{
+		Rounds: 1,
+		Events: []model.ReviewEvent{
+			{Username: dave, Kind: model.ReviewEventApproval, CreatedAt: mrs[1].MergedAt, Estimated: true},
+		},
+	}
+	return mrs
+}
+
+func testReviewStats(t *testing.T, open openStore) {
+	t.Helper()
+	store := open(t, false)
+	if err := store.UpdateProjectCache(t.Context(), webID, webProject, reviewedMergeRequests()); err != nil {
+		t.Fatalf("UpdateProjectCache failed: %v", err)
+	}
+
+	stats, err := store.GetReviewStats(t.Context(), []string{webProject}, time.Time{}, base().Add(26*time.Hour))
+	if err != nil {
+		t.Fatalf("GetReviewStats failed: %v", err)
+	}
+
+	// Approvals without a note are counted, but left out of the time to approval and to response
+	wantProjects := map[string]model.ProjectReviewStats{
+		"web": {
+			MergeRequests:       2,
+			TimeToFirstComment:  model.Durations{Count: 1, Median: time.Hour, P75: time.Hour, P90: time.Hour},
+			TimeToFirstApproval: model.Durations{Count: 1, Median: 90 * time.Minute, P75: 90 * time.Minute, P90: 90 * time.Minute},
+			ReviewRounds:        1.5,
+		},
+	}
+	if !reflect.DeepEqual(stats.Projects, wantProjects) {
+		t.Errorf("Projects = %+v, want %+v", stats.Projects, wantProjects)
+	}
+
+	wantReviewers := map[string]model.ReviewerStats{
+		carol: {
+			Commented:           1,
+			Approved:            1,
+			TimeToFirstResponse: model.Durations{Count: 1, Median: time.Hour, P75: time.Hour, P90: time.Hour},
+		},
+		dave: {Approved: 2},
+	}
+	if !reflect.DeepEqual(stats.Reviewers, wantReviewers) {
+		t.Errorf("Reviewers = %+v, want %+v", stats.Reviewers, wantReviewers)
+	}
+}
+
+func testReviewerData(t *testing.T, open openStore) {
+	t.Helper()
+	store := open(t, false)
+	mrs := reviewedMergeRequests()
+	if err := store.UpdateProjectCache(t.Context(), webID, webProject, mrs); err != nil {
+		t.Fatalf("UpdateProjectCache failed: %v", err)
+	}
+
+	// Every merge request is counted once per reviewer and kind of event
+	stats, err := store.GetReviewerDataForDate(t.Context(), []string{webProject}, base().Add(26*time.Hour))
+	if err != nil {
+		t.Fatalf("GetReviewerDataForDate failed: %v", err)
+	}
+	want := map[string]map[string]model.ReviewCounts{
+		carol: {"web": {Approved: 1, Commented: 1}},
+		dave:  {"web": {Approved: 2}},
+	}
+	if !reflect.DeepEqual(stats.Reviewers, want) {
+		t.Errorf("Reviewers = %v, want %v", stats.Reviewers, want)
+	}
+	if wantTotal := (model.ReviewCounts{Approved: 3, Commented: 1}); stats.Total != wantTotal {
+		t.Errorf("Total = %+v, want %+v", stats.Total, wantTotal)
+	}
+
+	// Events are counted by their own time
+	stats, err = store.GetReviewerDataForRange(t.Context(), []string{webProject}, base().Add(time.Hour), base().Add(26*time.Hour))
+	if err != nil {
+		t.Fatalf("GetReviewerDataForRange failed: %v", err)
+	}
+	want = map[string]map[string]model.ReviewCounts{dave: {"web": {Approved: 1}}}
+	if !reflect.DeepEqual(stats.Reviewers, want) {
+		t.Errorf("Reviewers = %v, want %v", stats.Reviewers, want)
+	}
+
+	// Updating a merge request replaces its review
+	mrs[0].Review = model.MergeRequestReview{}
+	if err := store.UpdateProjectCache(t.Context(), webID, webProject, mrs[:1]); err != nil {
+		t.Fatalf("UpdateProjectCache failed: %v", err)
+	}
+	stats, err = store.GetReviewerDataForDate(t.Context(), []string{webProject}, base().Add(26*time.Hour))
+	if err != nil {
+		t.Fatalf("GetReviewerDataForDate failed: %v", err)
+	}
+	if !reflect.DeepEqual(stats.Reviewers, want) {
+		t.Errorf("Reviewers = %v, want %v", stats.Reviewers, want)
+	}
+}
+
+func testDeleteUsers(t *testing.T, open openStore) {
+	t.Helper()
+	store := open(t, false)
+	fillStore(t, store)
+	if err := store.UpdateProjectCache(t.Context(), webID, webProject, reviewedMergeRequests()); err != nil {
+		t.Fatalf("UpdateProjectCache failed: %v", err)
+	}
+
+	if err := store.DeleteUsers(t.Context(), []string{bob, dave}); err != nil {
+		t.Fatalf("DeleteUsers failed: %v", err)
+	}
+
+	// Neither their merge requests nor their reviews are counted
+	to := base().Add(365 * 24 * time.Hour)
+	assertDevelopers(t, getAggregatedData(t, store, time.Time{}, to), map[string]map[string]int{alice: {"web": 2}})
+
+	stats, err := store.GetReviewerDataForDate(t.Context(), []string{webProject}, to)
+	if err != nil {
+		t.Fatalf("GetReviewerDataForDate failed: %v", err)
+	}
+	if want := map[string]map[string]model.ReviewCounts{carol: {"web": {Approved: 1, Commented: 1}}}; !reflect.DeepEqual(stats.Reviewers, want) {
+		t.Errorf("Reviewers = %v, want %v", stats.Reviewers, want)
+	}
+}
+
+func testDeveloperStats(t *testing.T, open openStore) {
+	t.Helper()
+	store := open(t, false)
+	fillStore(t, store)
+
+	from, to := base().Add(24*time.Hour), base().Add(48*time.Hour)
+	stats, err := store.GetDeveloperStats(t.Context(), alice, []string{webProject, apiProject}, from, to)
+	if err != nil {
+		t.Fatalf("GetDeveloperStats failed: %v", err)
+	}
+
+	if stats.Total != 1 || stats.PreviousTotal != 1 {
+		t.Errorf("Total = %d, PreviousTotal = %d, want 1 and 1", stats.Total, stats.PreviousTotal)
+	}
+	if len(stats.LargestMRs) != 1 || stats.LargestMRs[0].IID != 2 {
+		t.Errorf("LargestMRs = %+v, want !2", stats.LargestMRs)
+	}
+	if len(stats.RecentMRs) != 2 || stats.RecentMRs[0].IID != 2 || stats.RecentMRs[1].IID != 1 {
+		t.Errorf("RecentMRs = %+v, want !2 and !1", stats.RecentMRs)
+	}
+	if summary := stats.RecentMRs[0]; summary.ProjectName != "web" || summary.Title != "Add logout" ||
+		!summary.MergedAt.Equal(base().Add(26*time.Hour)) || summary.IsRevert {
+		t.Errorf("RecentMRs[0] = %+v, want the merge request !2 of web", summary)
+	}
+}
+
+func testProjectStats(t *testing.T, open openStore) {
+	t.Helper()
+	store := open(t, true)
+	fillStore(t, store)
+
+	projectID := getAggregatedData(t, store, time.Time{}, base().Add(50*time.Hour)).ProjectIDs["web"]
+	stats, err := store.GetProjectStats(t.Context(), projectID, time.Time{}, base().Add(50*time.Hour))
+	if err != nil {
+		t.Fatalf("GetProjectStats failed: %v", err)
+	}
+
+	if stats.ProjectName != webProject || stats.Total != 2 || stats.BusFactor != 1 {
+		t.Errorf("GetProjectStats = %+v, want 2 merge requests of %s by one contributor", stats, webProject)
+	}
+
+	if _, err := store.GetProjectStats(t.Context(), projectID+100, time.Time{}, base()); !errors.Is(err, db.ErrNotFound) {
+		t.Errorf("GetProjectStats of an unknown project returned %v, want %v", err, db.ErrNotFound)
+	}
+}
+
+func newSyncRun(projectName string, startedAt time.Time, syncErr string) model.SyncRun {
+	return model.SyncRun{
+		ProjectName:   projectName,
+		StartedAt:     startedAt,
+		FinishedAt:    startedAt.Add(time.Minute),
+		Since:         startedAt.Add(-time.Hour),
+		MergeRequests: 3,
+		Error:         syncErr,
+	}
+}
+
+func assertSyncRun(t *testing.T, got, want model.SyncRun) {
+	t.Helper()
+
+	if got.ProjectName != want.ProjectName || !got.StartedAt.Equal(want.StartedAt) ||
+		!got.FinishedAt.Equal(want.FinishedAt) || !got.Since.Equal(want.Since) ||
+		got.MergeRequests != want.MergeRequests || got.Error != want.Error {
+		t.Errorf("sync run = %+v, want %+v", got, want)
+	}
+}
+
+func testSyncRuns(t *testing.T, open openStore) {
+	t.Helper()
+	store := open(t, false)
+
+	// Times of runs are compared regardless of their time zones
+	zone := time.FixedZone("UTC+3", 3*60*60)
+	runs := []model.SyncRun{
+		newSyncRun(webProject, base(), ""),
+		newSyncRun(webProject, base().Add(time.Hour).In(zone), "failed to fetch merge requests"),
+		newSyncRun(apiProject, base().Add(90*time.Minute), ""),
+		newSyncRun(webProject, base().Add(2*time.Hour), ""),
+	}
+	runs[3].Since = time.Time{}
+	for _, run := range runs {
+		if err := store.AddSyncRun(t.Context(), run); err != nil {
+			t.Fatalf("AddSyncRun failed: %v", err)
+		}
+	}
+
+	got, err := store.GetSyncRuns(t.Context(), webProject, 2)
+	if err != nil {
+		t.Fatalf("GetSyncRuns failed: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("GetSyncRuns returned %d runs, want 2", len(got))
+	}
+	assertSyncRun(t, got[0], runs[3])
+	assertSyncRun(t, got[1], runs[1])
+
+	got, err = store.GetSyncRuns(t.Context(), "", 10)
+	if err != nil {
+		t.Fatalf("GetSyncRuns failed: %v", err)
+	}
+	if len(got) != len(runs) {
+		t.Errorf("GetSyncRuns returned %d runs, want %d", len(got), len(runs))
+	}
+}
+
+func testSyncStatus(t *testing.T, open openStore) {
+	t.Helper()
+	store := open(t, false)
+	fillStore(t, store)
+
+	const failed = "group/failed"
+	runs := []model.SyncRun{
+		newSyncRun(webProject, base(), ""),
+		newSyncRun(webProject, base().Add(time.Hour), ""),
+		newSyncRun(failed, base(), "404 Not Found"),
+	}
+	for _, run := range runs {
+		if err := store.AddSyncRun(t.Context(), run); err != nil {
+			t.Fatalf("AddSyncRun failed: %v", err)
+		}
+	}
+
+	statuses, err := store.GetSyncStatus(t.Context())
+	if err != nil {
+		t.Fatalf("GetSyncStatus failed: %v", err)
+	}
+
+	// Tracked projects and the projects that were never synced successfully sorted by name
+	if len(statuses) != 3 {
+		t.Fatalf("GetSyncStatus = %+v, want 3 projects", statuses)
+	}
+	if status := statuses[0]; status.ProjectName != apiProject || status.ProjectID == 0 || status.LastRun != nil {
+		t.Errorf("status of %s = %+v, want a stored project without runs", apiProject, status)
+	}
+	if status := statuses[1]; status.ProjectName != failed || status.ProjectID != 0 || status.LastRun == nil {
+		t.Errorf("status of %s = %+v, want only its run", failed, status)
+	} else {
+		assertSyncRun(t, *status.LastRun, runs[2])
+	}
+	if status := statuses[2]; status.ProjectName != webProject || status.LastUpdated.IsZero() || status.LastRun == nil {
+		t.Errorf("status of %s = %+v, want a stored project with runs", webProject, status)
+	} else {
+		assertSyncRun(t, *status.LastRun, runs[1])
+	}
+}
+
+func testSyncRequests(t *testing.T, open openStore) {
+	t.Helper()
+	queue, ok := open(t, false).(updater.SyncQueue)
+	if !ok {
+		t.Skip("the store doesn't keep sync requests")
+	}
+
+	if _, ok, err := queue.TakeSyncRequest(t.Context()); err != nil || ok {
+		t.Fatalf("TakeSyncRequest of an empty queue = %v, %v, want nothing", ok, err)
+	}
+
+	requests := []model.SyncRequest{
+		{},
+		{ProjectName: webProject, Since: base(), Rebuild: true},
+	}
+	for _, request := range requests {
+		if added, err := queue.AddSyncRequest(t.Context(), request, len(requests)); err != nil || !added {
+			t.Fatalf("AddSyncRequest = %v, %v, want it to be added", added, err)
+		}
+	}
+	if added, err := queue.AddSyncRequest(t.Context(), model.SyncRequest{ProjectName: apiProject}, len(requests)); err != nil || added {
+		t.Fatalf("AddSyncRequest to a full queue = %v, %v, want it to be dropped", added, err)
+	}
+
+	// Requests are taken in the order they were added
+	for _, want := range requests {
+		got, ok, err := queue.TakeSyncRequest(t.Context())
+		if err != nil || !ok {
+			t.Fatalf("TakeSyncRequest = %v, %v, want a request", ok, err)
+		}
+		if got.ProjectName != want.ProjectName || !got.Since.Equal(want.Since) || got.Rebuild != want.Rebuild {
+			t.Errorf("TakeSyncRequest = %+v, want %+v", got, want)
+		}
+	}
+
+	if _, ok, err := queue.TakeSyncRequest(t.Context()); err != nil || ok {
+		t.Errorf("TakeSyncRequest of an empty queue = %v, %v, want nothing", ok, err)
+	}
+}
+
+// testHistoryVersion checks that a project stored before a migration adding a field is fetched from scratch,
+// conn is a separate connection to the database of the filled store.
+func testHistoryVersion(t *testing.T, store db.Store, conn *sql.DB) {
+	t.Helper()
+
+	// A project stored before a migration adding a field is fetched from scratch
+	if _, err := conn.ExecContext(t.Context(), `UPDATE projects SET history_version = 0`); err != nil {
+		t.Fatalf("failed to reset history version: %v", err)
+	}
+	if _, err := store.GetLastUpdatedDate(t.Context(), webProject); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("GetLastUpdatedDate of an outdated project returned %v, want %v", err, sql.ErrNoRows)
+	}
+
+	// A partial rebuild doesn't store its whole history
+	mrs := webMergeRequests()
+	if err := store.ReplaceProjectCache(t.Context(), webID, webProject, base().Add(time.Hour), mrs[1:]); err != nil {
+		t.Fatalf("ReplaceProjectCache failed: %v", err)
+	}
+	if _, err := store.GetLastUpdatedDate(t.Context(), webProject); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("GetLastUpdatedDate after a partial rebuild returned %v, want %v", err, sql.ErrNoRows)
+	}
+
+	// But fetching all of it does
+	if err := store.ReplaceProjectCache(t.Context(), webID, webProject, time.Time{}, mrs); err != nil {
+		t.Fatalf("ReplaceProjectCache failed: %v", err)
+	}
+	if _, err := store.GetLastUpdatedDate(t.Context(), webProject); err != nil {
+		t.Errorf("GetLastUpdatedDate after a full rebuild failed: %v", err)
+	}
+
+	// And so does an update
+	if _, err := conn.ExecContext(t.Context(), `UPDATE projects SET history_version = 0`); err != nil {
+		t.Fatalf("failed to reset history version: %v", err)
+	}
+	if err := store.UpdateProjectCache(t.Context(), apiID, apiProject, apiMergeRequests()); err != nil {
+		t.Fatalf("UpdateProjectCache failed: %v", err)
+	}
+	if _, err := store.GetLastUpdatedDate(t.Context(), apiProject); err != nil {
+		t.Errorf("GetLastUpdatedDate after an update failed: %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"mr-metrics/internal/model"
+	"slices"
+	"strings"
+	"time"
+)
+
+// syncedProject is a project that was synced successfully at least once.
+type syncedProject struct {
+	id          int
+	name        string
+	lastUpdated time.Time
+	tracked     bool
+}
+
+func scanSyncedProjects(rows *sql.Rows) ([]syncedProject, error) {
+	var projects []syncedProject
+	for rows.Next() {
+		var project syncedProject
+		if err := rows.Scan(&project.id, &project.name, &project.lastUpdated, &project.tracked); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		projects = append(projects, project)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return projects, nil
+}
+
+func scanSyncRequest(row *sql.Row) (model.SyncRequest, bool, error) {
+	var (
+		request model.SyncRequest
+		since   sql.NullTime
+	)
+	err := row.Scan(&request.ProjectName, &since, &request.Rebuild)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.SyncRequest{}, false, nil
+	}
+	if err != nil {
+		return model.SyncRequest{}, false, fmt.Errorf("failed to take sync request: %w", err)
+	}
+
+	request.Since = since.Time
+	return request, true, nil
+}
+
+// buildSyncStatus combines the tracked projects with their latest sync runs.
+// Projects that have never been synced successfully are known only by their runs, so they are added as well.
+func buildSyncStatus(projects []syncedProject, latestRuns []model.SyncRun) []model.ProjectSyncStatus {
+	runs := make(map[string]model.SyncRun, len(latestRuns))
+	for _, run := range latestRuns {
+		runs[run.ProjectName] = run
+	}
+
+	statuses := make([]model.ProjectSyncStatus, 0, len(projects))
+	for _, project := range projects {
+		run, ok := runs[project.name]
+		delete(runs, project.name)
+		if !project.tracked {
+			continue
+		}
+
+		status := model.ProjectSyncStatus{
+			ProjectID:   project.id,
+			ProjectName: project.name,
+			LastUpdated: project.lastUpdated,
+		}
+		if ok {
+			status.LastRun = &run
+		}
+		statuses = append(statuses, status)
+	}
+
+	for _, run := range runs {
+		statuses = append(statuses, model.ProjectSyncStatus{
+			ProjectName: run.ProjectName,
+			LastRun:     &run,
+		})
+	}
+
+	slices.SortFunc(statuses, func(a, b model.ProjectSyncStatus) int {
+		return strings.Compare(a.ProjectName, b.ProjectName)
+	})
+	return statuses
+}
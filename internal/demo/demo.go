@@ -0,0 +1,225 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+// Package demo generates synthetic merge requests, so the UI can be tried without GitLab and a database.
+package demo
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"mr-metrics/internal/consts"
+	"mr-metrics/internal/model"
+	"time"
+)
+
+const (
+	// historyDays is how far back merge requests are generated
+	historyDays = 365
+	// revertShare is the share of merge requests reverting another one
+	revertShare = 0.03
+	// seed makes every demo show the same history
+	seed = 20250101
+
+	webURLFormat = "https://gitlab.example.com/%s/-/merge_requests/%d"
+
+	// Merge requests are merged during working hours
+	workdayStart  = 9 * time.Hour
+	workdayLength = 9 * time.Hour
+
+	meanChangesCount = 8
+	meanLeadTime     = 20 * time.Hour
+	maxReviewRounds  = 3
+	maxReviewers     = 2
+
+	// syncUpdates is the amount of hourly updates after the initial sync
+	syncUpdates            = 5
+	maxUpdateDuration      = 10 * time.Second
+	maxUpdateMergeRequests = 4
+)
+
+// Store is the part of the store the demo history is written to.
+type Store interface {
+	UpdateProjectCache(ctx context.Context, gitlabID int, projectName string, mrs []model.MergeRequest) error
+	SetTrackedProjects(ctx context.Context, projectNames []string) error
+	AddSyncRun(ctx context.Context, run model.SyncRun) error
+}
+
+// developer merges requests into some of the projects, the activity is the chance to merge one
+// into a project on a working day.
+type developer struct {
+	username string
+	activity map[string]float64
+}
+
+func projectNames() []string {
+	return []string{
+		"demo/backend",
+		"demo/frontend",
+		"demo/mobile",
+		"demo/infrastructure",
+		"demo/docs",
+	}
+}
+
+//nolint:mnd // activities are arbitrary
+func developers() []developer {
+	return []developer{
+		{"alice", map[string]float64{"demo/backend": 0.6, "demo/infrastructure": 0.2}},
+		{"bob", map[string]float64{"demo/backend": 0.4, "demo/docs": 0.1}},
+		{"carol", map[string]float64{"demo/frontend": 0.7, "demo/docs": 0.1}},
+		{"dave", map[string]float64{"demo/frontend": 0.3, "demo/mobile": 0.3}},
+		{"erin", map[string]float64{"demo/mobile": 0.5}},
+		{"frank", map[string]float64{"demo/infrastructure": 0.4, "demo/backend": 0.1}},
+		{"grace", map[string]float64{"demo/backend": 0.2, "demo/frontend": 0.2, "demo/mobile": 0.2}},
+		{"heidi", map[string]float64{"demo/docs": 0.3, "demo/frontend": 0.1}},
+	}
+}
+
+func titles() []string {
+	return []string{
+		"Fix flaky test",
+		"Add pagination",
+		"Update dependencies",
+		"Refactor configuration loading",
+		"Improve error messages",
+		"Add metrics endpoint",
+		"Fix typo",
+		"Speed up build",
+	}
+}
+
+func labels() []string {
+	return []string{"bug", "feature", "chore", "documentation"}
+}
+
+// Seed fills the store with a year of merge requests of several developers and projects before now
+// together with their reviews and a few sync runs.
+func Seed(ctx context.Context, store Store, now time.Time) error {
+	rng := rand.New(rand.NewPCG(seed, seed)) //nolint:gosec // synthetic data doesn't have to be cryptographically secure
+
+	projects := projectNames()
+	for i, projectName := range projects {
+		mrs := generateMergeRequests(rng, projectName, now)
+		if err := store.UpdateProjectCache(ctx, i+1, projectName, mrs); err != nil {
+			return fmt.Errorf("failed to store project %s: %w", projectName, err)
+		}
+
+		for _, run := range generateSyncRuns(rng, projectName, len(mrs), now) {
+			if err := store.AddSyncRun(ctx, run); err != nil {
+				return fmt.Errorf("failed to store sync run of project %s: %w", projectName, err)
+			}
+		}
+	}
+
+	return store.SetTrackedProjects(ctx, projects)
+}
+
+func generateMergeRequests(rng *rand.Rand, projectName string, now time.Time) []model.MergeRequest {
+	var mrs []model.MergeRequest
+	devs, titles, labels := developers(), titles(), labels()
+	start := now.Add(-historyDays * consts.OneDay).Truncate(consts.OneDay)
+
+	for day := start; day.Before(now); day = day.Add(consts.OneDay) {
+		if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			continue
+		}
+
+		for _, dev := range devs {
+			if rng.Float64() >= dev.activity[projectName] {
+				continue
+			}
+
+			mergedAt := day.Add(workdayStart + randDuration(rng, workdayLength))
+			if mergedAt.After(now) {
+				continue
+			}
+
+			mr := model.MergeRequest{
+				IID:          len(mrs) + 1,
+				Title:        titles[rng.IntN(len(titles))],
+				Username:     dev.username,
+				SourceBranch: fmt.Sprintf("%s/change-%d", dev.username, len(mrs)+1),
+				TargetBranch: "main",
+				Labels:       []string{labels[rng.IntN(len(labels))]},
+				WebURL:       fmt.Sprintf(webURLFormat, projectName, len(mrs)+1),
+				// Most merge requests are small, a few are huge
+				ChangesCount: 1 + int(rng.ExpFloat64()*meanChangesCount),
+				// Most merge requests are merged within a day, a few wait for weeks
+				CreatedAt: mergedAt.Add(-time.Hour - time.Duration(rng.ExpFloat64()*float64(meanLeadTime))),
+				MergedAt:  mergedAt,
+			}
+			if rng.Float64() < revertShare {
+				mr.Title = fmt.Sprintf("Revert %q", mr.Title)
+			}
+			mr.Review = generateReview(rng, devs, mr)
+
+			mrs = append(mrs, mr)
+		}
+	}
+
+	return mrs
+}
+
+// generateReview makes one or two other developers comment on the merge request and approve it before it was merged.
+func generateReview(rng *rand.Rand, devs []developer, mr model.MergeRequest) model.MergeRequestReview {
+	review := model.MergeRequestReview{Rounds: 1 + rng.IntN(maxReviewRounds)}
+	leadTime := mr.MergedAt.Sub(mr.CreatedAt)
+
+	for _, i := range rng.Perm(len(devs))[:maxReviewers] {
+		reviewer := devs[i].username
+		if reviewer == mr.Username || (len(review.Events) > 0 && rng.IntN(maxReviewers) == 0) {
+			continue
+		}
+
+		// Reviewers comment during the first half of the lead time
+		commentedAt := mr.CreatedAt.Add(randDuration(rng, leadTime/2)) //nolint:mnd // half
+		review.Events = append(review.Events,
+			model.ReviewEvent{Username: reviewer, Kind: model.ReviewEventComment, CreatedAt: commentedAt},
+			model.ReviewEvent{
+				Username:  reviewer,
+				Kind:      model.ReviewEventApproval,
+				CreatedAt: commentedAt.Add(randDuration(rng, mr.MergedAt.Sub(commentedAt))),
+			},
+		)
+	}
+
+	return review
+}
+
+// generateSyncRuns returns the initial sync of the whole history followed by hourly updates,
+// one of which failed.
+func generateSyncRuns(rng *rand.Rand, projectName string, mergeRequests int, now time.Time) []model.SyncRun {
+	startedAt := now.Add(-(syncUpdates + 1) * time.Hour)
+	runs := []model.SyncRun{{
+		ProjectName:   projectName,
+		StartedAt:     startedAt,
+		FinishedAt:    startedAt.Add(time.Minute + randDuration(rng, time.Minute)),
+		MergeRequests: mergeRequests,
+	}}
+
+	failed := rng.IntN(syncUpdates)
+	for i := range syncUpdates {
+		startedAt = startedAt.Add(time.Hour)
+		run := model.SyncRun{
+			ProjectName:   projectName,
+			StartedAt:     startedAt,
+			FinishedAt:    startedAt.Add(randDuration(rng, maxUpdateDuration)),
+			Since:         startedAt.Add(-consts.OneDay),
+			MergeRequests: rng.IntN(maxUpdateMergeRequests),
+		}
+		if i == failed {
+			run.MergeRequests = 0
+			run.Error = "failed to fetch data: API returned 502"
+		}
+		runs = append(runs, run)
+	}
+
+	return runs
+}
+
+// randDuration returns a random duration in [0, d).
+func randDuration(rng *rand.Rand, d time.Duration) time.Duration {
+	return time.Duration(rng.Int64N(int64(d)))
+}
@@ -0,0 +1,168 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+// Package export writes the developer × repository table in the formats shared by the web UI and the command line.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mr-metrics/internal/model"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	totalLabel = "TOTAL"
+	// textPadding is the amount of spaces between columns of the text table.
+	textPadding = 2
+)
+
+// Stats is the JSON representation of the table.
+type Stats struct {
+	Date       string                    `json:"date,omitempty"`
+	From       string                    `json:"from,omitempty"`
+	To         string                    `json:"to,omitempty"`
+	Developers []string                  `json:"developers"`
+	Projects   []string                  `json:"projects"`
+	Counts     map[string]map[string]int `json:"counts"`
+	DevTotals  map[string]int            `json:"dev_totals"`
+	RepoTotals map[string]int            `json:"repo_totals"`
+	Total      int                       `json:"total"`
+}
+
+func NewStats(data *model.AggregatedStats) Stats {
+	total := 0
+	for _, count := range data.RepoTotals {
+		total += count
+	}
+
+	projects := data.Projects
+	if projects == nil {
+		projects = []string{}
+	}
+
+	return Stats{
+		Date:       data.DateString,
+		From:       data.DateFromString,
+		To:         data.DateToString,
+		Developers: SortedDevelopers(data),
+		Projects:   projects,
+		Counts:     data.Developers,
+		DevTotals:  data.DevTotals,
+		RepoTotals: data.RepoTotals,
+		Total:      total,
+	}
+}
+
+// Table returns the same table as the HTML view: a header row, a row per developer
+// and a totals row, with the projects in the order of AggregatedStats.Projects
+// and the totals in the last column.
+func Table(data *model.AggregatedStats) [][]any {
+	header := make([]any, 0, len(data.Projects)+2)
+	header = append(header, "Developer")
+	for _, project := range data.Projects {
+		header = append(header, project)
+	}
+	header = append(header, totalLabel)
+
+	table := [][]any{header}
+	for _, username := range SortedDevelopers(data) {
+		row := make([]any, 0, len(header))
+		row = append(row, username)
+		for _, project := range data.Projects {
+			row = append(row, data.Developers[username][project])
+		}
+		row = append(row, data.DevTotals[username])
+		table = append(table, row)
+	}
+
+	totals := make([]any, 0, len(header))
+	totals = append(totals, totalLabel)
+	total := 0
+	for _, project := range data.Projects {
+		totals = append(totals, data.RepoTotals[project])
+		total += data.RepoTotals[project]
+	}
+	totals = append(totals, total)
+
+	return append(table, totals)
+}
+
+// WriteCSV writes the table as CSV. Names starting like a formula are prefixed with a quote,
+// so spreadsheets opening the file show them as text instead of evaluating them.
+func WriteCSV(w io.Writer, data *model.AggregatedStats) error {
+	writer := csv.NewWriter(w)
+	for _, row := range Table(data) {
+		record := records(row)
+		for i, cell := range row {
+			if name, ok := cell.(string); ok {
+				record[i] = escapeFormula(name)
+			}
+		}
+
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func WriteJSON(w io.Writer, data *model.AggregatedStats) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(NewStats(data))
+}
+
+// WriteText writes the table aligned for a terminal, numbers are aligned to the right.
+func WriteText(w io.Writer, data *model.AggregatedStats) error {
+	writer := tabwriter.NewWriter(w, 0, 0, textPadding, ' ', tabwriter.AlignRight)
+	for _, row := range Table(data) {
+		for _, cell := range records(row) {
+			if _, err := fmt.Fprint(writer, cell, "\t"); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintln(writer); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+func SortedDevelopers(data *model.AggregatedStats) []string {
+	developers := make([]string, 0, len(data.Developers))
+	for username := range data.Developers {
+		developers = append(developers, username)
+	}
+	slices.Sort(developers)
+	return developers
+}
+
+func records(row []any) []string {
+	record := make([]string, len(row))
+	for i, cell := range row {
+		switch v := cell.(type) {
+		case int:
+			record[i] = strconv.Itoa(v)
+		default:
+			record[i] = fmt.Sprint(v)
+		}
+	}
+	return record
+}
+
+// escapeFormula prefixes a cell starting with a formula character with a quote.
+func escapeFormula(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package export_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"mr-metrics/internal/export"
+	"mr-metrics/internal/model"
+)
+
+func newStats() *model.AggregatedStats {
+	return &model.AggregatedStats{
+		Developers: map[string]map[string]int{
+			"bob":   {"group/api": 2},
+			"alice": {"group/api": 1, "group/web": 3},
+		},
+		Projects:   []string{"group/web", "group/api"},
+		DevTotals:  map[string]int{"alice": 4, "bob": 2},
+		RepoTotals: map[string]int{"group/api": 3, "group/web": 3},
+	}
+}
+
+func TestTable(t *testing.T) {
+	t.Parallel()
+
+	want := [][]any{
+		{"Developer", "group/web", "group/api", "TOTAL"},
+		{"alice", 3, 1, 4},
+		{"bob", 0, 2, 2},
+		{"TOTAL", 3, 3, 6},
+	}
+	if got := export.Table(newStats()); !reflect.DeepEqual(got, want) {
+		t.Errorf("Table() = %v, want %v", got, want)
+	}
+}
+
+func TestTableWithoutMergeRequests(t *testing.T) {
+	t.Parallel()
+
+	want := [][]any{
+		{"Developer", "TOTAL"},
+		{"TOTAL", 0},
+	}
+	if got := export.Table(&model.AggregatedStats{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Table() = %v, want %v", got, want)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	t.Parallel()
+
+	var b strings.Builder
+	if err := export.WriteCSV(&b, newStats()); err != nil {
+		t.Fatalf("WriteCSV failed: %v", err)
+	}
+
+	want := "Developer,group/web,group/api,TOTAL\n" +
+		"alice,3,1,4\n" +
+		"bob,0,2,2\n" +
+		"TOTAL,3,3,6\n"
+	if b.String() != want {
+		t.Errorf("WriteCSV() = %q, want %q", b.String(), want)
+	}
+}
+
+func TestWriteCSVEscapesFormulas(t *testing.T) {
+	t.Parallel()
+
+	data := &model.AggregatedStats{
+		Developers: map[string]map[string]int{
+			"=HYPERLINK(\"http://x\")": {"+group/web": 1},
+			"-bot":                     {"+group/web": 1},
+			"@admin":                   {"+group/web": 1},
+			"a=b":                      {"+group/web": 1},
+		},
+		Projects:   []string{"+group/web"},
+		DevTotals:  map[string]int{"=HYPERLINK(\"http://x\")": 1, "-bot": 1, "@admin": 1, "a=b": 1},
+		RepoTotals: map[string]int{"+group/web": 4},
+	}
+
+	var b strings.Builder
+	if err := export.WriteCSV(&b, data); err != nil {
+		t.Fatalf("WriteCSV failed: %v", err)
+	}
+
+	want := "Developer,'+group/web,TOTAL\n" +
+		"'-bot,1,1\n" +
+		"\"'=HYPERLINK(\"\"http://x\"\")\",1,1\n" +
+		"'@admin,1,1\n" +
+		"a=b,1,1\n" +
+		"TOTAL,4,4\n"
+	if b.String() != want {
+		t.Errorf("WriteCSV() = %q, want %q", b.String(), want)
+	}
+}
@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"mr-metrics/internal/export"
+	"net/http"
+)
+
+type projectsResponse struct {
+	Projects []string `json:"projects"`
+}
+
+type developerResponse struct {
+	Username string `json:"username"`
+	Total    int    `json:"total"`
+}
+
+type developersResponse struct {
+	Developers []developerResponse `json:"developers"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func (h *StatsHandler) handleAPIStats(w http.ResponseWriter, r *http.Request) {
+	data, err := h.statsForRequest(r)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, export.NewStats(data))
+}
+
+func (h *StatsHandler) handleAPIProjects(w http.ResponseWriter, r *http.Request) {
+	projectNames, err := h.store.GetTrackedProjects(r.Context())
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, projectsResponse{Projects: nonNil(projectNames)})
+}
+
+func (h *StatsHandler) handleAPIDevelopers(w http.ResponseWriter, r *http.Request) {
+	data, err := h.statsForRequest(r)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	developers := make([]developerResponse, 0, len(data.Developers))
+	for _, username := range export.SortedDevelopers(data) {
+		developers = append(developers, developerResponse{
+			Username: username,
+			Total:    data.DevTotals[username],
+		})
+	}
+
+	writeJSON(w, http.StatusOK, developersResponse{Developers: developers})
+}
+
+func nonNil(items []string) []string {
+	if items == nil {
+		return []string{}
+	}
+	return items
+}
+
+// writeAPIError responds with the message of a bad request error or with a generic message otherwise,
+// so the internal errors are not exposed.
+func writeAPIError(w http.ResponseWriter, err error) {
+	if msg, ok := badRequestMessage(err); ok {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: msg})
+		return
+	}
+
+	log.Printf("API request failed: %v", err)
+	writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "Failed to retrieve data"})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Failed to write JSON response: %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package handlers_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"mr-metrics/internal/config"
+	"mr-metrics/internal/db"
+	"mr-metrics/internal/demo"
+	"mr-metrics/internal/handlers"
+)
+
+// newDemoServer serves the pages and the API the way the demo mode does.
+func newDemoServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	cfg, err := config.LoadDemo("")
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	store := db.NewMemoryStore(cfg.ExcludeReverts)
+	if err := demo.Seed(t.Context(), store, time.Now()); err != nil {
+		t.Fatalf("failed to generate demo data: %v", err)
+	}
+
+	server := httptest.NewServer(handlers.NewRouter(store, nil, config.Static(cfg)))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// get requests the path and returns the body of the response if it has the wanted status.
+func get(t *testing.T, server *httptest.Server, path string, wantStatus int) string {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL+path, nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("GET %s failed: %v", path, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read response of %s: %v", path, err)
+	}
+	if resp.StatusCode != wantStatus {
+		t.Fatalf("GET %s = %d, want %d: %s", path, resp.StatusCode, wantStatus, body)
+	}
+	return string(body)
+}
+
+func TestDemoPages(t *testing.T) {
+	t.Parallel()
+
+	server := newDemoServer(t)
+	pages := []struct {
+		path string
+		want string
+	}{
+		{path: "/", want: "alice"},
+		{path: "/?from=2000-01-01&to=2100-01-01", want: "backend"},
+		{path: "/lead-time", want: "frontend"},
+		{path: "/reviews", want: "carol"},
+		{path: "/developers/alice", want: "alice"},
+		{path: "/projects/1", want: "demo/backend"},
+		{path: "/status", want: "API returned 502"},
+		{path: "/static/style.css", want: "body"},
+		{path: "/export.csv", want: "Developer,"},
+	}
+	for _, page := range pages {
+		t.Run(page.path, func(t *testing.T) {
+			t.Parallel()
+
+			if body := get(t, server, page.path, http.StatusOK); !strings.Contains(body, page.want) {
+				t.Errorf("GET %s doesn't contain %q", page.path, page.want)
+			}
+		})
+	}
+}
+
+func TestDemoErrors(t *testing.T) {
+	t.Parallel()
+
+	server := newDemoServer(t)
+	get(t, server, "/projects/100", http.StatusNotFound)
+	get(t, server, "/?date=yesterday", http.StatusBadRequest)
+	get(t, server, "/api/v1/stats?from=2025-02-01&to=2025-01-01", http.StatusBadRequest)
+
+	// The demo mode doesn't sync projects
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, server.URL+"/api/v1/sync", nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("POST /api/v1/sync failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusAccepted {
+		t.Errorf("POST /api/v1/sync = %d, want it to be refused", resp.StatusCode)
+	}
+}
+
+func TestDemoAPI(t *testing.T) {
+	t.Parallel()
+
+	server := newDemoServer(t)
+
+	var projects struct {
+		Projects []string `json:"projects"`
+	}
+	if err := json.Unmarshal([]byte(get(t, server, "/api/v1/projects", http.StatusOK)), &projects); err != nil {
+		t.Fatalf("failed to decode projects: %v", err)
+	}
+	wantProjects := []string{"demo/backend", "demo/docs", "demo/frontend", "demo/infrastructure", "demo/mobile"}
+	if !reflect.DeepEqual(projects.Projects, wantProjects) {
+		t.Errorf("projects = %v, want %v", projects.Projects, wantProjects)
+	}
+
+	var developers struct {
+		Developers []struct {
+			Username string `json:"username"`
+			Total    int    `json:"total"`
+		} `json:"developers"`
+	}
+	body := get(t, server, "/api/v1/developers?from=2000-01-01&to=2100-01-01", http.StatusOK)
+	if err := json.Unmarshal([]byte(body), &developers); err != nil {
+		t.Fatalf("failed to decode developers: %v", err)
+	}
+	if wantDevelopers := 8; len(developers.Developers) != wantDevelopers {
+		t.Errorf("developers = %+v, want all %d demo developers", developers.Developers, wantDevelopers)
+	}
+	for _, developer := range developers.Developers {
+		if developer.Total == 0 {
+			t.Errorf("developer %s merged nothing in a year", developer.Username)
+		}
+	}
+
+	for _, path := range []string{"/api/v1/stats", "/api/v1/lead-time", "/api/v1/reviews", "/api/v1/sync-runs"} {
+		var response map[string]any
+		if err := json.Unmarshal([]byte(get(t, server, path, http.StatusOK)), &response); err != nil {
+			t.Errorf("GET %s returned invalid JSON: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"mr-metrics/internal/consts"
+	"mr-metrics/internal/dates"
+	"mr-metrics/internal/model"
+	"mr-metrics/internal/web"
+	"net/http"
+	"time"
+)
+
+// defaultHistoryPeriod is the period the history of a developer or a project is shown for
+// when "from" is not provided.
+const defaultHistoryPeriod = 12 * 7 * consts.OneDay
+
+type DeveloperStore interface {
+	GetTrackedProjects(ctx context.Context) ([]string, error)
+	GetDeveloperStats(ctx context.Context, username string, projectNames []string, from, to time.Time) (*model.DeveloperStats, error)
+}
+
+type DeveloperHandler struct {
+	store DeveloperStore
+	tmpl  *template.Template
+}
+
+func NewDeveloperHandler(store DeveloperStore) *DeveloperHandler {
+	return &DeveloperHandler{
+		store: store,
+		tmpl:  web.TemplateDeveloper(),
+	}
+}
+
+func (h *DeveloperHandler) handleDeveloper(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	from, to, err := parseDateRange(query.Get("from"), query.Get("to"), time.Now().Add(-defaultHistoryPeriod))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	projectNames, err := h.store.GetTrackedProjects(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	data, err := h.store.GetDeveloperStats(r.Context(), r.PathValue("username"), projectNames, dates.StartOfDay(from), dates.EndOfDay(to))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if data.Total == 0 && data.PreviousTotal == 0 && len(data.RecentMRs) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	data.DateFromString = from.Format(dates.Layout)
+	data.DateToString = to.Format(dates.Layout)
+
+	if err := web.TemplateExec(w, h.tmpl, data); err != nil {
+		http.Error(w, fmt.Errorf("template error: %w", err).Error(), http.StatusInternalServerError)
+	}
+}
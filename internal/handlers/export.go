@@ -0,0 +1,87 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"mr-metrics/internal/export"
+	"mr-metrics/internal/model"
+	"net/http"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const exportSheetName = "Merged requests"
+
+func (h *StatsHandler) handleExportCSV(w http.ResponseWriter, r *http.Request) {
+	data, err := h.statsForRequest(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", contentDisposition(data, "csv"))
+
+	if err := export.WriteCSV(w, data); err != nil {
+		log.Printf("Failed to write CSV export: %v", err)
+	}
+}
+
+func (h *StatsHandler) handleExportXLSX(w http.ResponseWriter, r *http.Request) {
+	data, err := h.statsForRequest(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	file, err := buildXLSX(data)
+	if err != nil {
+		http.Error(w, "Failed to build XLSX file", http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", contentDisposition(data, "xlsx"))
+
+	if err := file.Write(w); err != nil {
+		log.Printf("Failed to write XLSX export: %v", err)
+	}
+}
+
+func buildXLSX(data *model.AggregatedStats) (*excelize.File, error) {
+	file := excelize.NewFile()
+	if err := file.SetSheetName(file.GetSheetName(0), exportSheetName); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	for i, row := range export.Table(data) {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		if err := file.SetSheetRow(exportSheetName, cell, &row); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+
+	return file, nil
+}
+
+func contentDisposition(data *model.AggregatedStats, extension string) string {
+	filename := "merged-requests"
+	switch {
+	case data.DateString != "":
+		filename += "-" + data.DateString
+	case data.DateFromString != "":
+		filename += "-" + data.DateFromString + "-" + data.DateToString
+	}
+	return fmt.Sprintf("attachment; filename=%q", filename+"."+extension)
+}
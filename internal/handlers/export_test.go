@@ -0,0 +1,133 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"mr-metrics/internal/config"
+	"mr-metrics/internal/model"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// fakeStatsStore returns the same stats for any period and records the requested one.
+type fakeStatsStore struct {
+	from, to time.Time
+}
+
+func (s *fakeStatsStore) GetTrackedProjects(context.Context) ([]string, error) {
+	return []string{"group/web", "group/api"}, nil
+}
+
+func (s *fakeStatsStore) GetAggregatedDataForDate(
+	_ context.Context, _ []string, targetDate time.Time,
+) (*model.AggregatedStats, error) {
+	s.to = targetDate
+	return newExportStats(), nil
+}
+
+func (s *fakeStatsStore) GetAggregatedDataForRange(
+	_ context.Context, _ []string, from, to time.Time,
+) (*model.AggregatedStats, error) {
+	s.from, s.to = from, to
+	return newExportStats(), nil
+}
+
+func (s *fakeStatsStore) GetReviewerDataForDate(
+	context.Context, []string, time.Time,
+) (*model.ReviewerAggregatedStats, error) {
+	return &model.ReviewerAggregatedStats{}, nil
+}
+
+func (s *fakeStatsStore) GetReviewerDataForRange(
+	context.Context, []string, time.Time, time.Time,
+) (*model.ReviewerAggregatedStats, error) {
+	return &model.ReviewerAggregatedStats{}, nil
+}
+
+func newExportStats() *model.AggregatedStats {
+	return &model.AggregatedStats{
+		Developers: map[string]map[string]int{
+			"bob":   {"group/api": 2},
+			"alice": {"group/api": 1, "group/web": 3},
+		},
+		Projects:   []string{"group/web", "group/api"},
+		DevTotals:  map[string]int{"alice": 4, "bob": 2},
+		RepoTotals: map[string]int{"group/api": 3, "group/web": 3},
+	}
+}
+
+func TestHandleExportCSV(t *testing.T) {
+	t.Parallel()
+
+	store := &fakeStatsStore{}
+	h := NewStatsHandler(store, config.Static(&config.Config{}))
+
+	w := httptest.NewRecorder()
+	h.handleExportCSV(w, httptest.NewRequest(http.MethodGet, "/export.csv?from=2025-01-01&to=2025-01-31", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if got, want := w.Header().Get("Content-Disposition"),
+		`attachment; filename="merged-requests-2025-01-01-2025-01-31.csv"`; got != want {
+		t.Errorf("Content-Disposition = %q, want %q", got, want)
+	}
+	if want := time.Date(2025, 1, 31, 23, 59, 59, 999999999, time.UTC); !store.to.Equal(want) {
+		t.Errorf("stats requested till %v, want %v", store.to, want)
+	}
+
+	want := "Developer,group/web,group/api,TOTAL\nalice,3,1,4\nbob,0,2,2\nTOTAL,3,3,6\n"
+	if w.Body.String() != want {
+		t.Errorf("body = %q, want %q", w.Body.String(), want)
+	}
+}
+
+func TestHandleExportXLSX(t *testing.T) {
+	t.Parallel()
+
+	h := NewStatsHandler(&fakeStatsStore{}, config.Static(&config.Config{}))
+
+	w := httptest.NewRecorder()
+	h.handleExportXLSX(w, httptest.NewRequest(http.MethodGet, "/export.xlsx?date=2025-01-31", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if got, want := w.Header().Get("Content-Disposition"),
+		`attachment; filename="merged-requests-2025-01-31.xlsx"`; got != want {
+		t.Errorf("Content-Disposition = %q, want %q", got, want)
+	}
+
+	file, err := excelize.OpenReader(w.Body)
+	if err != nil {
+		t.Fatalf("failed to open XLSX: %v", err)
+	}
+	defer file.Close()
+
+	if got := file.GetSheetList(); !reflect.DeepEqual(got, []string{exportSheetName}) {
+		t.Errorf("sheets = %v, want %v", got, []string{exportSheetName})
+	}
+
+	rows, err := file.GetRows(exportSheetName)
+	if err != nil {
+		t.Fatalf("failed to read rows: %v", err)
+	}
+	want := [][]string{
+		{"Developer", "group/web", "group/api", "TOTAL"},
+		{"alice", "3", "1", "4"},
+		{"bob", "0", "2", "2"},
+		{"TOTAL", "3", "3", "6"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %v, want %v", rows, want)
+	}
+}
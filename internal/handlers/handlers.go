@@ -5,6 +5,9 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"log"
 	"mr-metrics/internal/config"
 	"mr-metrics/internal/db"
 	"net/http"
@@ -13,19 +16,61 @@ import (
 
 const defaultServerTimeout = 3 * time.Second
 
-func Start(db *db.PostgresStore, cfg *config.Config) error {
+// Start serves HTTP requests till the context is done, then waits for the active requests
+// to finish for ShutdownTimeout. The trigger is nil if this instance doesn't sync projects.
+func Start(ctx context.Context, db db.Store, trigger SyncTrigger, cfg *config.Live) error {
+	server := http.Server{
+		Addr:              ":" + cfg.Get().Port,
+		ReadHeaderTimeout: defaultServerTimeout,
+		Handler:           NewRouter(db, trigger, cfg),
+	}
+
+	shutdownErr := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		log.Printf("Shutting down HTTP server")
+
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cfg.Get().ShutdownTimeout)
+		defer cancel()
+		shutdownErr <- server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return <-shutdownErr
+}
+
+// NewRouter returns the handler of the pages and the API, the trigger is nil if this instance doesn't sync projects.
+func NewRouter(db db.Store, trigger SyncTrigger, cfg *config.Live) http.Handler {
 	mux := http.NewServeMux()
 
 	stats := NewStatsHandler(db, cfg)
+	leadTime := NewLeadTimeHandler(db)
+	reviews := NewReviewHandler(db)
+	developers := NewDeveloperHandler(db)
+	projects := NewProjectHandler(db)
+	status := NewStatusHandler(db, cfg)
+	sync := NewSyncHandler(trigger, db, cfg)
 
 	mux.HandleFunc("GET /", stats.handleStatsByDate)
 	mux.HandleFunc("GET /static/style.css", handleStyle)
+	mux.HandleFunc("GET /export.csv", stats.handleExportCSV)
+	mux.HandleFunc("GET /export.xlsx", stats.handleExportXLSX)
+	mux.HandleFunc("GET /lead-time", leadTime.handleLeadTime)
+	mux.HandleFunc("GET /reviews", reviews.handleReviews)
+	mux.HandleFunc("GET /developers/{username}", developers.handleDeveloper)
+	mux.HandleFunc("GET /projects/{id}", projects.handleProject)
+	mux.HandleFunc("GET /status", status.handleStatus)
 
-	server := http.Server{
-		Addr:              ":" + cfg.Port,
-		ReadHeaderTimeout: defaultServerTimeout,
-		Handler:           mux,
-	}
+	mux.HandleFunc("GET /api/v1/stats", stats.handleAPIStats)
+	mux.HandleFunc("GET /api/v1/projects", stats.handleAPIProjects)
+	mux.HandleFunc("GET /api/v1/developers", stats.handleAPIDevelopers)
+	mux.HandleFunc("GET /api/v1/lead-time", leadTime.handleAPILeadTime)
+	mux.HandleFunc("GET /api/v1/reviews", reviews.handleAPIReviews)
+	mux.HandleFunc("GET /api/v1/sync-runs", status.handleAPISyncRuns)
+	mux.HandleFunc("POST /api/v1/sync", sync.handleAPISync)
 
-	return server.ListenAndServe()
+	return mux
 }
@@ -0,0 +1,115 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"mr-metrics/internal/dates"
+	"mr-metrics/internal/model"
+	"mr-metrics/internal/web"
+	"net/http"
+	"time"
+)
+
+type LeadTimeStore interface {
+	GetTrackedProjects(ctx context.Context) ([]string, error)
+	GetLeadTimes(ctx context.Context, projectNames []string, from, to time.Time) (*model.LeadTimeStats, error)
+}
+
+type LeadTimeHandler struct {
+	store LeadTimeStore
+	tmpl  *template.Template
+}
+
+type durationsResponse struct {
+	Count         int     `json:"count"`
+	MedianSeconds float64 `json:"median_seconds"`
+	P75Seconds    float64 `json:"p75_seconds"`
+	P90Seconds    float64 `json:"p90_seconds"`
+}
+
+type leadTimeResponse struct {
+	From       string                       `json:"from"`
+	To         string                       `json:"to"`
+	Developers map[string]durationsResponse `json:"developers"`
+	Projects   map[string]durationsResponse `json:"projects"`
+	Total      durationsResponse            `json:"total"`
+}
+
+func NewLeadTimeHandler(store LeadTimeStore) *LeadTimeHandler {
+	return &LeadTimeHandler{
+		store: store,
+		tmpl:  web.TemplateLeadTime(),
+	}
+}
+
+func (h *LeadTimeHandler) handleLeadTime(w http.ResponseWriter, r *http.Request) {
+	data, err := h.leadTimeForRequest(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := web.TemplateExec(w, h.tmpl, data); err != nil {
+		http.Error(w, fmt.Errorf("template error: %w", err).Error(), http.StatusInternalServerError)
+	}
+}
+
+func (h *LeadTimeHandler) handleAPILeadTime(w http.ResponseWriter, r *http.Request) {
+	data, err := h.leadTimeForRequest(r)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, leadTimeResponse{
+		From:       data.DateFromString,
+		To:         data.DateToString,
+		Developers: durationsResponses(data.Developers),
+		Projects:   durationsResponses(data.Projects),
+		Total:      newDurationsResponse(data.Total),
+	})
+}
+
+func (h *LeadTimeHandler) leadTimeForRequest(r *http.Request) (*model.LeadTimeStats, error) {
+	query := r.URL.Query()
+	from, to, err := parseDateRange(query.Get("from"), query.Get("to"), time.Now().Add(-defaultPeriod))
+	if err != nil {
+		return nil, err
+	}
+
+	projectNames, err := h.store.GetTrackedProjects(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := h.store.GetLeadTimes(r.Context(), projectNames, dates.StartOfDay(from), dates.EndOfDay(to))
+	if err != nil {
+		return nil, err
+	}
+
+	data.DateFromString = from.Format(dates.Layout)
+	data.DateToString = to.Format(dates.Layout)
+	return data, nil
+}
+
+func durationsResponses(m map[string]model.Durations) map[string]durationsResponse {
+	result := make(map[string]durationsResponse, len(m))
+	for key, durations := range m {
+		result[key] = newDurationsResponse(durations)
+	}
+	return result
+}
+
+func newDurationsResponse(d model.Durations) durationsResponse {
+	return durationsResponse{
+		Count:         d.Count,
+		MedianSeconds: d.Median.Seconds(),
+		P75Seconds:    d.P75.Seconds(),
+		P90Seconds:    d.P90.Seconds(),
+	}
+}
@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"mr-metrics/internal/dates"
+	"mr-metrics/internal/db"
+	"mr-metrics/internal/model"
+	"mr-metrics/internal/web"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type ProjectStore interface {
+	GetProjectStats(ctx context.Context, projectID int, from, to time.Time) (*model.ProjectStats, error)
+}
+
+type ProjectHandler struct {
+	store ProjectStore
+	tmpl  *template.Template
+}
+
+func NewProjectHandler(store ProjectStore) *ProjectHandler {
+	return &ProjectHandler{
+		store: store,
+		tmpl:  web.TemplateProject(),
+	}
+}
+
+func (h *ProjectHandler) handleProject(w http.ResponseWriter, r *http.Request) {
+	projectID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	query := r.URL.Query()
+	from, to, err := parseDateRange(query.Get("from"), query.Get("to"), time.Now().Add(-defaultHistoryPeriod))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	data, err := h.store.GetProjectStats(r.Context(), projectID, dates.StartOfDay(from), dates.EndOfDay(to))
+	if errors.Is(err, db.ErrNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	data.DateFromString = from.Format(dates.Layout)
+	data.DateToString = to.Format(dates.Layout)
+
+	if err := web.TemplateExec(w, h.tmpl, data); err != nil {
+		http.Error(w, fmt.Errorf("template error: %w", err).Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,121 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"mr-metrics/internal/dates"
+	"mr-metrics/internal/model"
+	"mr-metrics/internal/web"
+	"net/http"
+	"time"
+)
+
+type ReviewStore interface {
+	GetTrackedProjects(ctx context.Context) ([]string, error)
+	GetReviewStats(ctx context.Context, projectNames []string, from, to time.Time) (*model.ReviewStats, error)
+}
+
+type ReviewHandler struct {
+	store ReviewStore
+	tmpl  *template.Template
+}
+
+type projectReviewResponse struct {
+	MergeRequests       int               `json:"merge_requests"`
+	TimeToFirstComment  durationsResponse `json:"time_to_first_comment"`
+	TimeToFirstApproval durationsResponse `json:"time_to_first_approval"`
+	ReviewRounds        float64           `json:"review_rounds"`
+}
+
+type reviewerResponse struct {
+	Commented           int               `json:"commented"`
+	Approved            int               `json:"approved"`
+	TimeToFirstResponse durationsResponse `json:"time_to_first_response"`
+}
+
+type reviewResponse struct {
+	From      string                           `json:"from"`
+	To        string                           `json:"to"`
+	Projects  map[string]projectReviewResponse `json:"projects"`
+	Reviewers map[string]reviewerResponse      `json:"reviewers"`
+}
+
+func NewReviewHandler(store ReviewStore) *ReviewHandler {
+	return &ReviewHandler{
+		store: store,
+		tmpl:  web.TemplateReviews(),
+	}
+}
+
+func (h *ReviewHandler) handleReviews(w http.ResponseWriter, r *http.Request) {
+	data, err := h.reviewsForRequest(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := web.TemplateExec(w, h.tmpl, data); err != nil {
+		http.Error(w, fmt.Errorf("template error: %w", err).Error(), http.StatusInternalServerError)
+	}
+}
+
+func (h *ReviewHandler) handleAPIReviews(w http.ResponseWriter, r *http.Request) {
+	data, err := h.reviewsForRequest(r)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	projects := make(map[string]projectReviewResponse, len(data.Projects))
+	for projectName, stats := range data.Projects {
+		projects[projectName] = projectReviewResponse{
+			MergeRequests:       stats.MergeRequests,
+			TimeToFirstComment:  newDurationsResponse(stats.TimeToFirstComment),
+			TimeToFirstApproval: newDurationsResponse(stats.TimeToFirstApproval),
+			ReviewRounds:        stats.ReviewRounds,
+		}
+	}
+
+	reviewers := make(map[string]reviewerResponse, len(data.Reviewers))
+	for username, stats := range data.Reviewers {
+		reviewers[username] = reviewerResponse{
+			Commented:           stats.Commented,
+			Approved:            stats.Approved,
+			TimeToFirstResponse: newDurationsResponse(stats.TimeToFirstResponse),
+		}
+	}
+
+	writeJSON(w, http.StatusOK, reviewResponse{
+		From:      data.DateFromString,
+		To:        data.DateToString,
+		Projects:  projects,
+		Reviewers: reviewers,
+	})
+}
+
+func (h *ReviewHandler) reviewsForRequest(r *http.Request) (*model.ReviewStats, error) {
+	query := r.URL.Query()
+	from, to, err := parseDateRange(query.Get("from"), query.Get("to"), time.Now().Add(-defaultPeriod))
+	if err != nil {
+		return nil, err
+	}
+
+	projectNames, err := h.store.GetTrackedProjects(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := h.store.GetReviewStats(r.Context(), projectNames, dates.StartOfDay(from), dates.EndOfDay(to))
+	if err != nil {
+		return nil, err
+	}
+
+	data.DateFromString = from.Format(dates.Layout)
+	data.DateToString = to.Format(dates.Layout)
+	return data, nil
+}
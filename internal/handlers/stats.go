@@ -5,37 +5,67 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"html/template"
 	"mr-metrics/internal/config"
+	"mr-metrics/internal/consts"
+	"mr-metrics/internal/dates"
 	"mr-metrics/internal/model"
 	"mr-metrics/internal/web"
 	"net/http"
+	"net/url"
 	"time"
 )
 
+const (
+	// defaultPeriod is the period metrics are calculated for when "from" is not provided.
+	defaultPeriod = 30 * consts.OneDay
+)
+
 type StatsStore interface {
-	GetAggregatedDataForDate(projectNames []string, targetDate time.Time) (*model.AggregatedStats, error)
+	GetTrackedProjects(ctx context.Context) ([]string, error)
+	GetAggregatedDataForDate(ctx context.Context, projectNames []string, targetDate time.Time) (*model.AggregatedStats, error)
+	GetAggregatedDataForRange(ctx context.Context, projectNames []string, from, to time.Time) (*model.AggregatedStats, error)
+	GetReviewerDataForDate(ctx context.Context, projectNames []string, targetDate time.Time) (*model.ReviewerAggregatedStats, error)
+	GetReviewerDataForRange(ctx context.Context, projectNames []string, from, to time.Time) (*model.ReviewerAggregatedStats, error)
 }
 
 type StatsHandler struct {
-	store StatsStore
-	cfg   *config.Config
-	tmpl  *template.Template
+	store         StatsStore
+	cfg           *config.Live
+	tmpl          *template.Template
+	reviewersTmpl *template.Template
+}
+
+// badRequestError is returned when the query parameters of a request can't be used to select stats.
+type badRequestError struct {
+	msg string
 }
 
-func NewStatsHandler(store StatsStore, cfg *config.Config) *StatsHandler {
+func (e badRequestError) Error() string {
+	return e.msg
+}
+
+func NewStatsHandler(store StatsStore, cfg *config.Live) *StatsHandler {
 	return &StatsHandler{
-		store: store,
-		cfg:   cfg,
-		tmpl:  web.TemplateStats(),
+		store:         store,
+		cfg:           cfg,
+		tmpl:          web.TemplateStats(),
+		reviewersTmpl: web.TemplateReviewers(),
 	}
 }
 
-func (h *StatsHandler) handleStats(w http.ResponseWriter, _ *http.Request) {
-	data, err := h.store.GetAggregatedDataForDate(h.cfg.ProjectNames, endOfDay(time.Now()))
+func (h *StatsHandler) handleStatsByDate(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("view") == "reviewers" {
+		h.handleReviewersByDate(w, r)
+		return
+	}
+
+	data, err := h.statsForRequest(r)
 	if err != nil {
-		http.Error(w, "Failed to get data", http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -44,41 +74,147 @@ func (h *StatsHandler) handleStats(w http.ResponseWriter, _ *http.Request) {
 	}
 }
 
-func (h *StatsHandler) handleStatsByDate(w http.ResponseWriter, r *http.Request) {
-	dateStr := r.URL.Query().Get("date")
-	if dateStr == "" {
-		h.handleStats(w, r) // Default for current date
-		return
+// statsForRequest selects stats according to the query parameters:
+// "date" for the stats up to the date, "from" and "to" for the merges made inside the range,
+// and the stats up to the current date otherwise. "team" leaves only the members of the team.
+func (h *StatsHandler) statsForRequest(r *http.Request) (*model.AggregatedStats, error) {
+	period, err := parseStatsPeriod(r.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+
+	team := r.URL.Query().Get("team")
+	members, err := h.teamMembers(team)
+	if err != nil {
+		return nil, err
+	}
+
+	projectNames, err := h.store.GetTrackedProjects(r.Context())
+	if err != nil {
+		return nil, err
 	}
 
-	targetDate, err := time.Parse("2006-01-02", dateStr)
+	var data *model.AggregatedStats
+	if period.IsRange {
+		data, err = h.store.GetAggregatedDataForRange(r.Context(), projectNames, dates.StartOfDay(period.From), dates.EndOfDay(period.To))
+	} else {
+		data, err = h.store.GetAggregatedDataForDate(r.Context(), projectNames, dates.EndOfDay(period.To))
+	}
 	if err != nil {
-		http.Error(w, "Invalid date format. Use YYYY-MM-DD", http.StatusBadRequest)
+		return nil, err
+	}
+
+	if team != "" {
+		filterDevelopers(data, members)
+	}
+
+	data.DateString, data.DateFromString, data.DateToString = period.Labels()
+	data.Team, data.Teams, data.Aliases = team, h.teamNames(), h.cfg.Get().Aliases
+	return data, nil
+}
+
+func (h *StatsHandler) handleReviewersByDate(w http.ResponseWriter, r *http.Request) {
+	data, err := h.reviewersForRequest(r)
+	if err != nil {
+		writeError(w, err)
 		return
 	}
 
-	data, err := h.store.GetAggregatedDataForDate(h.cfg.ProjectNames, endOfDay(targetDate))
+	if err := web.TemplateExec(w, h.reviewersTmpl, data); err != nil {
+		http.Error(w, fmt.Errorf("template error: %w", err).Error(), http.StatusInternalServerError)
+	}
+}
+
+func (h *StatsHandler) reviewersForRequest(r *http.Request) (*model.ReviewerAggregatedStats, error) {
+	period, err := parseStatsPeriod(r.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+
+	team := r.URL.Query().Get("team")
+	members, err := h.teamMembers(team)
+	if err != nil {
+		return nil, err
+	}
+
+	projectNames, err := h.store.GetTrackedProjects(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	var data *model.ReviewerAggregatedStats
+	if period.IsRange {
+		data, err = h.store.GetReviewerDataForRange(r.Context(), projectNames, dates.StartOfDay(period.From), dates.EndOfDay(period.To))
+	} else {
+		data, err = h.store.GetReviewerDataForDate(r.Context(), projectNames, dates.EndOfDay(period.To))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if team != "" {
+		filterReviewers(data, members)
+	}
+
+	data.DateString, data.DateFromString, data.DateToString = period.Labels()
+	data.Team, data.Teams, data.Aliases = team, h.teamNames(), h.cfg.Get().Aliases
+	return data, nil
+}
+
+// parseStatsPeriod selects the period according to the "date", "from" and "to" query parameters.
+func parseStatsPeriod(query url.Values) (dates.Period, error) {
+	period, err := dates.ParsePeriod(query.Get("date"), query.Get("from"), query.Get("to"))
 	if err != nil {
-		http.Error(w, "Failed to retrieve historical data", http.StatusInternalServerError)
+		return dates.Period{}, dateError(err)
+	}
+	return period, nil
+}
+
+// writeError responds with the message of a bad request error or with a generic message otherwise.
+func writeError(w http.ResponseWriter, err error) {
+	if msg, ok := badRequestMessage(err); ok {
+		http.Error(w, msg, http.StatusBadRequest)
 		return
 	}
+	http.Error(w, "Failed to retrieve data", http.StatusInternalServerError)
+}
 
-	data.DateString = targetDate.Format("2006-01-02")
+func badRequestMessage(err error) (string, bool) {
+	var badRequest badRequestError
+	if errors.As(err, &badRequest) {
+		return badRequest.msg, true
+	}
+	return "", false
+}
 
-	if err := h.tmpl.Execute(w, data); err != nil {
-		http.Error(w, "Template error", http.StatusInternalServerError)
+// parseDateRange parses the bounds of a date range. Empty "to" stands for the current date
+// and empty "from" stands for defaultFrom.
+func parseDateRange(fromStr, toStr string, defaultFrom time.Time) (time.Time, time.Time, error) {
+	from, to, err := dates.ParseRange(fromStr, toStr, defaultFrom)
+	if err != nil {
+		return time.Time{}, time.Time{}, dateError(err)
 	}
+	return from, to, nil
 }
 
-func endOfDay(date time.Time) time.Time {
-	return time.Date(
-		date.Year(),
-		date.Month(),
-		date.Day(),
-		23,
-		59,
-		59,
-		999999999,
-		date.Location(),
-	).UTC()
+// parseDate parses a date query parameter, an invalid date is a bad request.
+func parseDate(value string) (time.Time, error) {
+	date, err := dates.Parse(value)
+	if err != nil {
+		return time.Time{}, dateError(err)
+	}
+	return date, nil
+}
+
+// dateError turns an error of parsing the dates of a request into a bad request
+// with the message naming the query parameters.
+func dateError(err error) error {
+	switch {
+	case errors.Is(err, dates.ErrFromRequired):
+		return badRequestError{msg: "Parameter \"from\" is required when \"to\" is provided"}
+	case errors.Is(err, dates.ErrFromAfterTo):
+		return badRequestError{msg: "Parameter \"from\" must not be after \"to\""}
+	default:
+		return badRequestError{msg: "Invalid date format. Use YYYY-MM-DD"}
+	}
 }
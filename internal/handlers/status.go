@@ -0,0 +1,164 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"mr-metrics/internal/config"
+	"mr-metrics/internal/model"
+	"mr-metrics/internal/web"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// staleCycles is the amount of update cycles after which the data of a project is stale.
+	// NOTE(danilax86): One cycle isn't enough, the next one may be still running.
+	staleCycles = 2
+	// recentRunsLimit is the amount of the latest sync runs shown on the status page.
+	recentRunsLimit = 20
+	// defaultSyncRunsLimit is the amount of sync runs returned by the API unless the limit is provided.
+	defaultSyncRunsLimit = 100
+)
+
+type StatusStore interface {
+	GetSyncStatus(ctx context.Context) ([]model.ProjectSyncStatus, error)
+	GetSyncRuns(ctx context.Context, projectName string, limit int) ([]model.SyncRun, error)
+}
+
+type StatusHandler struct {
+	store StatusStore
+	cfg   *config.Live
+	tmpl  *template.Template
+}
+
+type syncRunResponse struct {
+	Project       string     `json:"project"`
+	StartedAt     time.Time  `json:"started_at"`
+	FinishedAt    time.Time  `json:"finished_at"`
+	Since         *time.Time `json:"since,omitempty"`
+	MergeRequests int        `json:"merge_requests"`
+	Error         string     `json:"error,omitempty"`
+}
+
+type projectSyncStatusResponse struct {
+	Project     string     `json:"project"`
+	LastUpdated *time.Time `json:"last_updated,omitempty"`
+	Stale       bool       `json:"stale"`
+}
+
+type syncRunsResponse struct {
+	Projects []projectSyncStatusResponse `json:"projects"`
+	Runs     []syncRunResponse           `json:"runs"`
+}
+
+func NewStatusHandler(store StatusStore, cfg *config.Live) *StatusHandler {
+	return &StatusHandler{
+		store: store,
+		cfg:   cfg,
+		tmpl:  web.TemplateStatus(),
+	}
+}
+
+func (h *StatusHandler) handleStatus(w http.ResponseWriter, r *http.Request) {
+	projects, err := h.projectsStatus(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	runs, err := h.store.GetSyncRuns(r.Context(), "", recentRunsLimit)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	data := model.SyncStatus{
+		Projects:   projects,
+		StaleAfter: h.staleAfter(""),
+		RecentRuns: runs,
+	}
+	if err := web.TemplateExec(w, h.tmpl, data); err != nil {
+		http.Error(w, fmt.Errorf("template error: %w", err).Error(), http.StatusInternalServerError)
+	}
+}
+
+func (h *StatusHandler) handleAPISyncRuns(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit := defaultSyncRunsLimit
+	if limitStr := query.Get("limit"); limitStr != "" {
+		var err error
+		if limit, err = strconv.Atoi(limitStr); err != nil || limit < 1 {
+			writeAPIError(w, badRequestError{msg: "Parameter \"limit\" must be a positive number"})
+			return
+		}
+	}
+
+	projects, err := h.projectsStatus(r.Context())
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	runs, err := h.store.GetSyncRuns(r.Context(), query.Get("project"), limit)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	resp := syncRunsResponse{
+		Projects: make([]projectSyncStatusResponse, 0, len(projects)),
+		Runs:     make([]syncRunResponse, 0, len(runs)),
+	}
+	for _, project := range projects {
+		resp.Projects = append(resp.Projects, projectSyncStatusResponse{
+			Project:     project.ProjectName,
+			LastUpdated: nonZeroTime(project.LastUpdated),
+			Stale:       project.Stale,
+		})
+	}
+	for _, run := range runs {
+		resp.Runs = append(resp.Runs, syncRunResponse{
+			Project:       run.ProjectName,
+			StartedAt:     run.StartedAt,
+			FinishedAt:    run.FinishedAt,
+			Since:         nonZeroTime(run.Since),
+			MergeRequests: run.MergeRequests,
+			Error:         run.Error,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// projectsStatus returns the sync status of projects marking the ones which data is stale.
+func (h *StatusHandler) projectsStatus(ctx context.Context) ([]model.ProjectSyncStatus, error) {
+	projects, err := h.store.GetSyncStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range projects {
+		staleAfter := h.staleAfter(projects[i].ProjectName)
+		projects[i].Stale = projects[i].LastUpdated.IsZero() || time.Since(projects[i].LastUpdated) > staleAfter
+	}
+	return projects, nil
+}
+
+// staleAfter returns the age of stale data of the project, empty name gives the default one.
+func (h *StatusHandler) staleAfter(projectName string) time.Duration {
+	return staleCycles * h.cfg.Get().Project(projectName).SyncInterval
+}
+
+func nonZeroTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
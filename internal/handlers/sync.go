@@ -0,0 +1,143 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"mr-metrics/internal/config"
+	"mr-metrics/internal/model"
+	"mr-metrics/internal/service/updater"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type SyncTrigger interface {
+	Enqueue(ctx context.Context, request model.SyncRequest) error
+}
+
+// SyncStore returns the projects a sync can be requested for besides the configured ones.
+type SyncStore interface {
+	GetTrackedProjects(ctx context.Context) ([]string, error)
+}
+
+type SyncHandler struct {
+	trigger SyncTrigger
+	store   SyncStore
+	cfg     *config.Live
+}
+
+type syncResponse struct {
+	Status string `json:"status"`
+}
+
+func NewSyncHandler(trigger SyncTrigger, store SyncStore, cfg *config.Live) *SyncHandler {
+	return &SyncHandler{
+		trigger: trigger,
+		store:   store,
+		cfg:     cfg,
+	}
+}
+
+// handleAPISync enqueues a sync of all projects or of the one given by "project" parameter.
+// Merge requests are fetched since "since" date if it's given, "rebuild" replaces the stored merge requests
+// merged since then (or the whole history) with the fetched ones.
+func (h *SyncHandler) handleAPISync(w http.ResponseWriter, r *http.Request) {
+	if h.cfg.Get().APIToken == "" {
+		writeJSON(w, http.StatusForbidden, errorResponse{Error: "Sync API is disabled, set API_TOKEN to enable it"})
+		return
+	}
+
+	if !h.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "Invalid API token"})
+		return
+	}
+
+	if h.trigger == nil {
+		writeJSON(w, http.StatusServiceUnavailable, errorResponse{Error: "Projects are not synced by this instance"})
+		return
+	}
+
+	request, err := parseSyncRequest(r)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	if request.ProjectName != "" {
+		known, err := h.isKnownProject(r.Context(), request.ProjectName)
+		if err != nil {
+			writeAPIError(w, err)
+			return
+		}
+		if !known {
+			writeJSON(w, http.StatusNotFound, errorResponse{
+				Error: fmt.Sprintf("Project %s is neither configured nor tracked", request.ProjectName),
+			})
+			return
+		}
+	}
+
+	if err := h.trigger.Enqueue(r.Context(), request); err != nil {
+		if errors.Is(err, updater.ErrQueueFull) || errors.Is(err, updater.ErrNotLeader) {
+			writeJSON(w, http.StatusServiceUnavailable, errorResponse{Error: err.Error()})
+			return
+		}
+
+		log.Printf("Failed to request sync: %v", err)
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: "Failed to request sync"})
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, syncResponse{Status: "queued"})
+}
+
+// authorized checks the bearer token of a request in constant time.
+func (h *SyncHandler) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(h.cfg.Get().APIToken)) == 1
+}
+
+// isKnownProject reports whether the project is configured or was discovered by topics or groups,
+// so a misspelled name is rejected instead of failing in the background.
+func (h *SyncHandler) isKnownProject(ctx context.Context, projectName string) (bool, error) {
+	if slices.Contains(h.cfg.Get().ProjectNames, projectName) {
+		return true, nil
+	}
+
+	tracked, err := h.store.GetTrackedProjects(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get tracked projects: %w", err)
+	}
+	return slices.Contains(tracked, projectName), nil
+}
+
+func parseSyncRequest(r *http.Request) (model.SyncRequest, error) {
+	request := model.SyncRequest{ProjectName: strings.TrimSpace(r.FormValue("project"))}
+
+	if sinceStr := r.FormValue("since"); sinceStr != "" {
+		since, err := parseDate(sinceStr)
+		if err != nil {
+			return model.SyncRequest{}, err
+		}
+		request.Since = since
+	}
+
+	if rebuildStr := r.FormValue("rebuild"); rebuildStr != "" {
+		rebuild, err := strconv.ParseBool(rebuildStr)
+		if err != nil {
+			return model.SyncRequest{}, badRequestError{msg: "Parameter \"rebuild\" must be a boolean"}
+		}
+		request.Rebuild = rebuild
+	}
+
+	return request, nil
+}
@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package handlers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"mr-metrics/internal/config"
+	"mr-metrics/internal/db"
+	"mr-metrics/internal/handlers"
+	"mr-metrics/internal/model"
+)
+
+const apiToken = "secret"
+
+// fakeTrigger records the requested syncs.
+type fakeTrigger struct {
+	mu       sync.Mutex
+	requests []model.SyncRequest
+}
+
+func (f *fakeTrigger) Enqueue(_ context.Context, request model.SyncRequest) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, request)
+	return nil
+}
+
+func TestAPISyncProject(t *testing.T) {
+	t.Parallel()
+
+	// group/web is configured but not synced yet, group/api was discovered and stored
+	store := db.NewMemoryStore(false)
+	if err := store.UpdateProjectCache(t.Context(), 1, "group/api", nil); err != nil {
+		t.Fatalf("failed to store project: %v", err)
+	}
+	if err := store.SetTrackedProjects(t.Context(), []string{"group/api"}); err != nil {
+		t.Fatalf("failed to track project: %v", err)
+	}
+
+	trigger := &fakeTrigger{}
+	cfg := &config.Config{ProjectNames: []string{"group/web"}, APIToken: apiToken}
+	server := httptest.NewServer(handlers.NewRouter(store, trigger, config.Static(cfg)))
+	t.Cleanup(server.Close)
+
+	tests := []struct {
+		project    string
+		wantStatus int
+	}{
+		{project: "group/web", wantStatus: http.StatusAccepted},
+		{project: "group/api", wantStatus: http.StatusAccepted},
+		{project: "group/wbe", wantStatus: http.StatusNotFound},
+		{project: "", wantStatus: http.StatusAccepted},
+	}
+	for _, tt := range tests {
+		form := url.Values{"project": {tt.project}}
+		req, err := http.NewRequestWithContext(
+			t.Context(), http.MethodPost, server.URL+"/api/v1/sync", strings.NewReader(form.Encode()),
+		)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+apiToken)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		resp, err := server.Client().Do(req)
+		if err != nil {
+			t.Fatalf("POST /api/v1/sync failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.wantStatus {
+			t.Errorf("sync of %q returned %d, want %d", tt.project, resp.StatusCode, tt.wantStatus)
+		}
+	}
+
+	trigger.mu.Lock()
+	defer trigger.mu.Unlock()
+	if len(trigger.requests) != 3 {
+		t.Errorf("requested syncs = %+v, want all but the unknown project", trigger.requests)
+	}
+}
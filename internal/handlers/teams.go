@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package handlers
+
+import (
+	"fmt"
+	"maps"
+	"mr-metrics/internal/model"
+	"slices"
+)
+
+// teamMembers returns the usernames of the team members, nil if the team is empty, i.e. everyone is shown.
+func (h *StatsHandler) teamMembers(team string) ([]string, error) {
+	if team == "" {
+		return nil, nil
+	}
+
+	members, ok := h.cfg.Get().Teams[team]
+	if !ok {
+		return nil, badRequestError{msg: fmt.Sprintf("Unknown team %q", team)}
+	}
+	return members, nil
+}
+
+// teamNames returns the names of the configured teams in ascending order.
+func (h *StatsHandler) teamNames() []string {
+	return slices.Sorted(maps.Keys(h.cfg.Get().Teams))
+}
+
+// filterDevelopers keeps only the team members in the table and recalculates the totals of the repos.
+func filterDevelopers(data *model.AggregatedStats, members []string) {
+	data.RepoTotals = make(map[string]int)
+	for username, counts := range data.Developers {
+		if !slices.Contains(members, username) {
+			delete(data.Developers, username)
+			delete(data.DevTotals, username)
+			continue
+		}
+
+		for project, count := range counts {
+			data.RepoTotals[project] += count
+		}
+	}
+}
+
+// filterReviewers keeps only the team members in the table and recalculates the totals.
+func filterReviewers(data *model.ReviewerAggregatedStats, members []string) {
+	data.RepoTotals = make(map[string]model.ReviewCounts)
+	data.Total = model.ReviewCounts{}
+	for username, counts := range data.Reviewers {
+		if !slices.Contains(members, username) {
+			delete(data.Reviewers, username)
+			delete(data.ReviewerTotals, username)
+			continue
+		}
+
+		for project, c := range counts {
+			total := data.RepoTotals[project]
+			total.Approved += c.Approved
+			total.Commented += c.Commented
+			data.RepoTotals[project] = total
+		}
+
+		data.Total.Approved += data.ReviewerTotals[username].Approved
+		data.Total.Commented += data.ReviewerTotals[username].Commented
+	}
+}
@@ -0,0 +1,41 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package model
+
+import "time"
+
+// MergeRequestSummary is a merge request shown in a list with a link to GitLab.
+type MergeRequestSummary struct {
+	ProjectName  string
+	IID          int
+	Title        string
+	WebURL       string
+	ChangesCount int
+	MergedAt     time.Time
+	// Whether the title starts with "Revert"
+	IsRevert bool
+}
+
+// WeeklyMerges is the amount of merged requests per week and project.
+type WeeklyMerges struct {
+	// Beginnings of the weeks (Mondays) in ascending order
+	Weeks    []string
+	Projects []string
+	// Amount of merged requests per week per project
+	Counts map[string]map[string]int
+}
+
+// DeveloperStats describes the merged requests of a single developer inside a date range.
+type DeveloperStats struct {
+	Username       string
+	DateFromString string
+	DateToString   string
+	Weekly         WeeklyMerges
+	Total          int
+	// Amount of merged requests in the period of the same length right before the range
+	PreviousTotal int
+	LargestMRs    []MergeRequestSummary
+	RecentMRs     []MergeRequestSummary
+}
@@ -0,0 +1,26 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package model
+
+import "time"
+
+// Durations describes the distribution of durations of several merge requests.
+type Durations struct {
+	Count  int
+	Median time.Duration
+	P75    time.Duration
+	P90    time.Duration
+}
+
+// LeadTimeStats describes how long it took merge requests to get merged after they were created.
+type LeadTimeStats struct {
+	DateFromString string
+	DateToString   string
+	// Lead time per developer
+	Developers map[string]Durations
+	// Lead time per repo
+	Projects map[string]Durations
+	Total    Durations
+}
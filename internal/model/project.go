@@ -0,0 +1,58 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// ContributorShare is the part of merged requests of a project made by a contributor.
+type ContributorShare struct {
+	Username string
+	Count    int
+	// Share of all merged requests of the project from 0 to 1
+	Share float64
+}
+
+// ProjectStats describes the merged requests of a single project inside a date range.
+type ProjectStats struct {
+	ProjectID      int
+	ProjectName    string
+	DateFromString string
+	DateToString   string
+	LastUpdated    time.Time
+	Weekly         WeeklyMerges
+	Total          int
+	// Top contributors followed by "others" if there are more of them
+	Contributors []ContributorShare
+	// The least amount of contributors who made more than half of merged requests
+	BusFactor int
+	// The latest sync attempts
+	SyncRuns []SyncRun
+}
+
+// projectSourceSeparator separates the source from the path in the names of projects of additional GitLab sources,
+// paths of GitLab projects can't contain it.
+const projectSourceSeparator = ":"
+
+// QualifiedProjectName returns the name a project is stored and shown by: its path for the default GitLab source
+// and its path prefixed by the source otherwise, so the same paths on different instances don't collide.
+func QualifiedProjectName(source, path string) string {
+	if source == "" {
+		return path
+	}
+	return source + projectSourceSeparator + path
+}
+
+// SplitProjectName returns the source and the path of a project by its qualified name,
+// the source is empty for the default one.
+func SplitProjectName(name string) (string, string) {
+	source, path, found := strings.Cut(name, projectSourceSeparator)
+	if !found {
+		return "", name
+	}
+	return source, path
+}
@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package model
+
+import "time"
+
+type ReviewEventKind string
+
+const (
+	ReviewEventComment  ReviewEventKind = "comment"
+	ReviewEventApproval ReviewEventKind = "approval"
+)
+
+// ReviewEvent is a comment or an approval left on a merge request by someone except its author.
+type ReviewEvent struct {
+	Username  string
+	Kind      ReviewEventKind
+	CreatedAt time.Time
+	// Whether CreatedAt is only the latest possible time of the event, such events are counted
+	// but left out of the time to review
+	Estimated bool
+}
+
+// MergeRequestReview describes the review of a single merge request.
+type MergeRequestReview struct {
+	Events []ReviewEvent
+	// Amount of times reviewers came back to the merge request after its author addressed the previous review
+	Rounds int
+}
+
+// ReviewStats describes how fast merge requests merged inside a date range were reviewed.
+type ReviewStats struct {
+	DateFromString string
+	DateToString   string
+	Projects       map[string]ProjectReviewStats
+	Reviewers      map[string]ReviewerStats
+}
+
+type ProjectReviewStats struct {
+	MergeRequests int
+	// Time from creating a merge request to the first comment of a reviewer
+	TimeToFirstComment Durations
+	// Time from creating a merge request to the first approval
+	TimeToFirstApproval Durations
+	// Average amount of review rounds per merge request
+	ReviewRounds float64
+}
+
+type ReviewerStats struct {
+	// Amount of merge requests the reviewer commented on
+	Commented int
+	// Amount of merge requests the reviewer approved
+	Approved int
+	// Time from creating a merge request to the first comment or approval of the reviewer
+	TimeToFirstResponse Durations
+}
+
+// ReviewCounts is the amount of merge requests a reviewer approved and commented on.
+type ReviewCounts struct {
+	Approved  int
+	Commented int
+}
+
+// ReviewerAggregatedStats is a reviewer × repository table, the counterpart of AggregatedStats for reviewers.
+type ReviewerAggregatedStats struct {
+	Reviewers      map[string]map[string]ReviewCounts
+	Projects       []string
+	DateString     string
+	DateFromString string
+	DateToString   string
+	// Total amount of reviewed merge requests per reviewer
+	ReviewerTotals map[string]ReviewCounts
+	// Total amount of reviewed merge requests per repo
+	RepoTotals map[string]ReviewCounts
+	Total      ReviewCounts
+	// Team the reviewers are filtered by, empty if they are not
+	Team string
+	// Names of the configured teams in ascending order
+	Teams []string
+	// Names shown instead of usernames
+	Aliases map[string]string
+}
@@ -9,11 +9,22 @@ import "time"
 type AggregatedStats struct {
 	Developers map[string]map[string]int
 	Projects   []string
+	// IDs of the projects by their names
+	ProjectIDs map[string]int
 	DateString string
+	// Bounds of the date range when only merges inside of it are counted
+	DateFromString string
+	DateToString   string
 	// Total amount of merged requests per developer
 	DevTotals map[string]int
 	// Total amount of merged requests per repo
 	RepoTotals map[string]int
+	// Team the developers are filtered by, empty if they are not
+	Team string
+	// Names of the configured teams in ascending order
+	Teams []string
+	// Names shown instead of usernames
+	Aliases map[string]string
 }
 
 type ProjectMRCounts struct {
@@ -23,6 +34,16 @@ type ProjectMRCounts struct {
 }
 
 type MergeRequest struct {
-	Username string
-	MergedAt time.Time
+	ProjectID    int
+	IID          int
+	Title        string
+	Username     string
+	SourceBranch string
+	TargetBranch string
+	Labels       []string
+	WebURL       string
+	ChangesCount int
+	CreatedAt    time.Time
+	MergedAt     time.Time
+	Review       MergeRequestReview
 }
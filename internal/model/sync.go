@@ -0,0 +1,52 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package model
+
+import "time"
+
+// SyncRun is a single attempt to update a project.
+type SyncRun struct {
+	ProjectName string
+	StartedAt   time.Time
+	FinishedAt  time.Time
+	// Merge requests updated since this time were fetched, zero if the whole history was fetched
+	Since         time.Time
+	MergeRequests int
+	// Empty if the sync succeeded
+	Error string
+}
+
+func (r SyncRun) Duration() time.Duration {
+	return r.FinishedAt.Sub(r.StartedAt)
+}
+
+// ProjectSyncStatus describes how up to date the data of a project is.
+type ProjectSyncStatus struct {
+	// Zero if the project was never synced successfully
+	ProjectID   int
+	ProjectName string
+	LastUpdated time.Time
+	// The latest sync attempt, nil if there were none
+	LastRun *SyncRun
+	// Whether the data is older than expected
+	Stale bool
+}
+
+type SyncStatus struct {
+	Projects   []ProjectSyncStatus
+	StaleAfter time.Duration
+	RecentRuns []SyncRun
+}
+
+// SyncRequest describes a sync requested manually.
+type SyncRequest struct {
+	// Empty to sync all projects
+	ProjectName string
+	// Zero to fetch merge requests updated since the last update
+	Since time.Time
+	// Whether the stored merge requests merged since Since (all of them if it's zero)
+	// are replaced with the fetched ones
+	Rebuild bool
+}
@@ -5,86 +5,440 @@
 package updater
 
 import (
+	"cmp"
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"mr-metrics/internal/consts"
 	"mr-metrics/internal/model"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"mr-metrics/internal/config"
 )
 
 type StatsUpdater interface {
-	UpdateProjectCache(projectID int, projectName string, counts []model.MergeRequest) error
-	GetLastUpdatedDate(projectName string) (time.Time, error)
+	UpdateProjectCache(ctx context.Context, gitlabID int, projectName string, counts []model.MergeRequest) error
+	ReplaceProjectCache(ctx context.Context, gitlabID int, projectName string, since time.Time, counts []model.MergeRequest) error
+	GetLastUpdatedDate(ctx context.Context, projectName string) (time.Time, error)
+	SetTrackedProjects(ctx context.Context, projectNames []string) error
+	DeleteUsers(ctx context.Context, usernames []string) error
+	AddSyncRun(ctx context.Context, run model.SyncRun) error
 }
 
 type StatsClient interface {
-	GetMergedMRCounts(projectName string, since time.Time) ([]model.MergeRequest, int, error)
+	GetMergedMRCounts(ctx context.Context, projectName string, since time.Time) ([]model.MergeRequest, int, error)
+	// DiscoverProjects returns the qualified names of the projects of the source matched by topics or groups.
+	DiscoverProjects(ctx context.Context, source string, topics, groups []string) ([]string, error)
+	GetMergeRequestReview(ctx context.Context, projectName string, mr model.MergeRequest) (model.MergeRequestReview, error)
+	GetMergeRequestChangesCount(ctx context.Context, projectName string, iid int) (int, error)
+}
+
+const (
+	// requestsQueueSize is the amount of requested syncs waiting for the current one to finish.
+	requestsQueueSize = 16
+	// requestsPollInterval is how often the leader checks for syncs requested on any replica.
+	requestsPollInterval = 5 * time.Second
+)
+
+var (
+	ErrQueueFull = errors.New("too many syncs are requested, try again later")
+	ErrNotLeader = errors.New("projects are synced by another replica, try again")
+)
+
+// Elector is implemented by stores shared by several replicas, so only one of them syncs projects at once.
+type Elector interface {
+	// Lead waits to become the leader and runs the function till the context is done.
+	// The function context is cancelled when the leadership is lost.
+	Lead(ctx context.Context, run func(ctx context.Context)) error
+	// TryLead runs the function if no other replica is the leader and reports whether it did.
+	TryLead(ctx context.Context, run func(ctx context.Context)) (bool, error)
+}
+
+// SyncQueue is implemented by stores shared by several replicas, so a sync requested on any of them
+// is stored till the leader runs it.
+type SyncQueue interface {
+	// AddSyncRequest stores a request unless the given amount of requests is waiting already
+	// and reports whether it was stored.
+	AddSyncRequest(ctx context.Context, request model.SyncRequest, limit int) (bool, error)
+	// TakeSyncRequest removes the oldest request and returns it, false if there are none.
+	TakeSyncRequest(ctx context.Context) (model.SyncRequest, bool, error)
 }
 
 type BackgroundUpdater struct {
-	cfg     *config.Config
+	cfg     *config.Live
 	updater StatsUpdater
 	ticker  *time.Ticker
-	gitlab  StatsClient
+	// reconcileTicker is nil if reconciliation is disabled
+	reconcileTicker   *time.Ticker
+	reconcileInterval time.Duration
+	// reloaded receives a value after the configuration is reloaded
+	reloaded <-chan struct{}
+	gitlab   StatsClient
+	requests chan model.SyncRequest
+	// queue is nil if requested syncs are kept in memory
+	queue SyncQueue
+	wg    sync.WaitGroup
+	// leading is set while this replica syncs projects
+	leading atomic.Bool
 }
 
-func New(store StatsUpdater, gitlab StatsClient, cfg *config.Config) *BackgroundUpdater {
-	return &BackgroundUpdater{
-		cfg:     cfg,
-		updater: store,
-		gitlab:  gitlab,
-		ticker:  time.NewTicker(cfg.CacheTTL),
+func New(store StatsUpdater, gitlab StatsClient, cfg *config.Live) *BackgroundUpdater {
+	u := &BackgroundUpdater{
+		cfg:      cfg,
+		updater:  store,
+		gitlab:   gitlab,
+		ticker:   time.NewTicker(cfg.Get().SyncTick()),
+		reloaded: cfg.Subscribe(),
+		requests: make(chan model.SyncRequest, requestsQueueSize),
+	}
+	if interval := cfg.Get().ReconcileInterval; interval > 0 {
+		u.reconcileTicker = time.NewTicker(interval)
+		u.reconcileInterval = interval
+	}
+	if queue, ok := store.(SyncQueue); ok {
+		u.queue = queue
 	}
+	return u
 }
 
+// Start updates all projects right away and then each of them every its sync interval till the context is done.
+// If the store is an Elector, projects are updated only while this replica is the leader.
+// Cancelling the context interrupts the current update, use Wait to wait for it to roll back.
 func (u *BackgroundUpdater) Start(ctx context.Context) {
-	go u.updateAllProjects()
-
-	go func() {
-		for {
-			select {
-			case <-u.ticker.C:
-				u.updateAllProjects()
-			case <-ctx.Done():
-				u.ticker.Stop()
-				return
+	u.wg.Go(func() {
+		elector, ok := u.updater.(Elector)
+		if !ok {
+			u.run(ctx)
+			return
+		}
+
+		if err := elector.Lead(ctx, u.run); err != nil && ctx.Err() == nil {
+			log.Printf("Leader election failed: %v", err)
+		}
+	})
+}
+
+// Enqueue requests a sync to run after the current one.
+// If the store is a SyncQueue, the request is stored for the leader, so it's accepted on any replica.
+func (u *BackgroundUpdater) Enqueue(ctx context.Context, request model.SyncRequest) error {
+	if u.queue != nil {
+		return enqueue(ctx, u.queue, request)
+	}
+
+	if !u.leading.Load() {
+		return ErrNotLeader
+	}
+
+	select {
+	case u.requests <- request:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Queue stores requested syncs for the leader on instances that don't sync projects themselves.
+type Queue struct {
+	queue SyncQueue
+}
+
+func NewQueue(queue SyncQueue) *Queue {
+	return &Queue{queue: queue}
+}
+
+// Enqueue stores a requested sync for the leader.
+func (q *Queue) Enqueue(ctx context.Context, request model.SyncRequest) error {
+	return enqueue(ctx, q.queue, request)
+}
+
+func enqueue(ctx context.Context, queue SyncQueue, request model.SyncRequest) error {
+	added, err := queue.AddSyncRequest(ctx, request, requestsQueueSize)
+	if err != nil {
+		return fmt.Errorf("failed to store sync request: %w", err)
+	}
+	if !added {
+		return ErrQueueFull
+	}
+	return nil
+}
+
+// run updates projects till the context is done.
+// Scheduled and requested syncs run one after another, so they never overlap.
+// Their errors are logged by updateProjects, so they are not handled here.
+func (u *BackgroundUpdater) run(ctx context.Context) {
+	u.leading.Store(true)
+	defer u.leading.Store(false)
+
+	u.updateProjects(ctx, model.SyncRequest{}, false)
+	u.ticker.Reset(u.cfg.Get().SyncTick())
+
+	var reconcile <-chan time.Time
+	if u.reconcileTicker != nil {
+		// NOTE(danilax86): The tickers are stopped when the leadership is lost,
+		// so they are restarted for every term.
+		u.reconcileTicker.Reset(u.reconcileInterval)
+		reconcile = u.reconcileTicker.C
+	}
+
+	var stored <-chan time.Time
+	if u.queue != nil {
+		poll := time.NewTicker(requestsPollInterval)
+		defer poll.Stop()
+		stored = poll.C
+	}
+
+	for {
+		select {
+		case <-u.ticker.C:
+			u.updateProjects(ctx, model.SyncRequest{}, true)
+			// NOTE(danilax86): The next update is counted from the end of this one,
+			// so a long update isn't followed by another one right away.
+			u.ticker.Reset(u.cfg.Get().SyncTick())
+		case <-reconcile:
+			// Merge requests deleted or unmerged recently are removed by replacing the recent ones
+			u.updateProjects(ctx, model.SyncRequest{Since: time.Now().Add(-u.cfg.Get().ReconcileWindow), Rebuild: true}, false)
+		case request := <-u.requests:
+			u.updateProjects(ctx, request, false)
+		case <-stored:
+			u.runStoredRequests(ctx)
+		case <-u.reloaded:
+			// New projects are synced right away and removed ones stop being tracked,
+			// the rest are synced when their new intervals pass
+			u.updateProjects(ctx, model.SyncRequest{}, true)
+			u.ticker.Reset(u.cfg.Get().SyncTick())
+		case <-ctx.Done():
+			u.ticker.Stop()
+			if u.reconcileTicker != nil {
+				u.reconcileTicker.Stop()
 			}
+			return
 		}
-	}()
+	}
 }
 
-func (u *BackgroundUpdater) updateAllProjects() {
-	for _, projectName := range u.cfg.ProjectNames {
-		lastUpdated, err := u.updater.GetLastUpdatedDate(projectName)
+// runStoredRequests runs the syncs stored by SyncQueue one after another till there are none left.
+func (u *BackgroundUpdater) runStoredRequests(ctx context.Context) {
+	for ctx.Err() == nil {
+		request, ok, err := u.queue.TakeSyncRequest(ctx)
 		if err != nil {
-			log.Printf("Failed to fetch last updated date for project %s. Fetch all merged requests", projectName)
+			log.Printf("Failed to take requested sync: %v", err)
+			return
+		}
+		if !ok {
+			return
+		}
+		u.updateProjects(ctx, request, false)
+	}
+}
 
-			// If the last updated date is not found, fetch all data
-			counts, projectID, err := u.gitlab.GetMergedMRCounts(projectName, time.Time{}.UTC())
-			if err != nil {
-				log.Printf("Failed to fetch data for project %s: %v", projectName, err)
-				continue
-			}
+// Sync updates the requested project or all projects once and returns the errors of the failed ones.
+// Unlike Start it doesn't wait for the leadership, so it is meant for one-shot syncs.
+func (u *BackgroundUpdater) Sync(ctx context.Context, request model.SyncRequest) error {
+	return u.updateProjects(ctx, request, false)
+}
+
+// Wait blocks until the updater is stopped and the current update is finished.
+func (u *BackgroundUpdater) Wait() {
+	u.wg.Wait()
+}
+
+// updateProjects updates the requested project or all projects in parallel, at most SyncConcurrency at once.
+// If onlyDue is set, the projects synced within their sync interval are skipped.
+// Errors are logged as they happen, the returned error joins all of them.
+func (u *BackgroundUpdater) updateProjects(ctx context.Context, request model.SyncRequest, onlyDue bool) error {
+	var (
+		mu   sync.Mutex
+		errs []error
+	)
+	addError := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err)
+	}
+
+	projectNames := []string{request.ProjectName}
+	if request.ProjectName == "" {
+		var err error
+		projectNames, err = u.resolveProjects(ctx)
+		if err != nil {
+			log.Printf("Failed to discover projects: %v", err)
+			addError(fmt.Errorf("failed to discover projects: %w", err))
+		} else if err := u.updater.SetTrackedProjects(ctx, projectNames); err != nil {
+			log.Printf("Failed to update tracked projects: %v", err)
+			addError(fmt.Errorf("failed to update tracked projects: %w", err))
+		}
 
-			if err := u.updater.UpdateProjectCache(projectID, projectName, counts); err != nil {
-				log.Printf("Failed to update cache for project %s: %v", projectName, err)
+		// NOTE(danilax86): Excluded users are only skipped on fetch, so the data stored
+		// before they were excluded is removed on the next full update.
+		if excluded := u.cfg.Get().ExcludedUsers; len(excluded) > 0 {
+			if err := u.updater.DeleteUsers(ctx, excluded); err != nil {
+				log.Printf("Failed to delete excluded users: %v", err)
+				addError(fmt.Errorf("failed to delete excluded users: %w", err))
 			}
+		}
+	}
+
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, u.cfg.Get().SyncConcurrency)
+	for _, projectName := range projectNames {
+		if onlyDue && !u.isDue(ctx, projectName) {
 			continue
 		}
 
-		// Add a delta (yesterday) to the last updated date to avoid losing requests
-		since := lastUpdated.Add(-1 * consts.OneDay)
+		select {
+		case workers <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return errors.Join(append(errs, ctx.Err())...)
+		}
+
+		wg.Go(func() {
+			defer func() { <-workers }()
+			if err := u.syncProject(ctx, projectName, request); err != nil {
+				addError(fmt.Errorf("project %s: %w", projectName, err))
+			}
+		})
+	}
+
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// isDue reports whether the sync interval of the project passed since its last update.
+func (u *BackgroundUpdater) isDue(ctx context.Context, projectName string) bool {
+	lastUpdated, err := u.updater.GetLastUpdatedDate(ctx, projectName)
+	if err != nil {
+		// The project was never synced
+		return true
+	}
+	return !time.Now().Before(lastUpdated.Add(u.cfg.Get().Project(projectName).SyncInterval))
+}
+
+// syncProject updates a project since its last update or since the requested time
+// and stores the attempt in the sync history.
+func (u *BackgroundUpdater) syncProject(ctx context.Context, projectName string, request model.SyncRequest) error {
+	since := request.Since.UTC()
+	startDate := u.cfg.Get().Project(projectName).StartDate
+
+	if since.IsZero() && !request.Rebuild {
+		lastUpdated, err := u.updater.GetLastUpdatedDate(ctx, projectName)
+		if err != nil {
+			// If the last updated date is not found, fetch all data
+			log.Printf("Failed to fetch last updated date for project %s. Fetch all merged requests", projectName)
+		} else {
+			// Add a delta (yesterday) to the last updated date to avoid losing requests
+			since = lastUpdated.Add(-1 * consts.OneDay)
+		}
+	}
+
+	// Merge requests merged before the start date are not stored, so they are not fetched either
+	if since.Before(startDate) {
+		since = startDate
+	}
+
+	var err error
+	run := model.SyncRun{ProjectName: projectName, StartedAt: time.Now(), Since: since}
+	run.MergeRequests, err = u.updateProject(ctx, projectName, since, request.Rebuild)
+	run.FinishedAt = time.Now()
+
+	if err != nil {
+		log.Printf("Failed to update project %s: %v", projectName, err)
+
+		// The sync was interrupted rather than failed
+		if ctx.Err() != nil {
+			return err
+		}
+		run.Error = err.Error()
+	}
+
+	if err := u.updater.AddSyncRun(ctx, run); err != nil {
+		log.Printf("Failed to store sync run of project %s: %v", projectName, err)
+	}
+	return err
+}
+
+// updateProject fetches merge requests of a project updated since the given time together with their reviews
+// and sizes and stores them. On rebuild they replace the stored merge requests merged since the given time.
+// It returns the amount of fetched merge requests. The whole update of a project is limited by SyncProjectTimeout.
+func (u *BackgroundUpdater) updateProject(ctx context.Context, projectName string, since time.Time, rebuild bool) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, u.cfg.Get().SyncProjectTimeout)
+	defer cancel()
 
-		counts, projectID, err := u.gitlab.GetMergedMRCounts(projectName, since)
+	mrs, gitlabID, err := u.gitlab.GetMergedMRCounts(ctx, projectName, since)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch data: %w", err)
+	}
+	mrs = u.filterMergeRequests(projectName, mrs)
+
+	for i := range mrs {
+		review, err := u.gitlab.GetMergeRequestReview(ctx, projectName, mrs[i])
+		if err != nil {
+			return len(mrs), fmt.Errorf("failed to fetch review: %w", err)
+		}
+		mrs[i].Review = u.filterReview(review)
+
+		changesCount, err := u.gitlab.GetMergeRequestChangesCount(ctx, projectName, mrs[i].IID)
 		if err != nil {
-			log.Printf("Failed to fetch data for project %s: %v", projectName, err)
+			return len(mrs), fmt.Errorf("failed to fetch changes count: %w", err)
+		}
+		mrs[i].ChangesCount = changesCount
+	}
+
+	if rebuild {
+		err = u.updater.ReplaceProjectCache(ctx, gitlabID, projectName, since, mrs)
+	} else {
+		err = u.updater.UpdateProjectCache(ctx, gitlabID, projectName, mrs)
+	}
+	if err != nil {
+		return len(mrs), fmt.Errorf("failed to update cache: %w", err)
+	}
+	return len(mrs), nil
+}
+
+// filterMergeRequests drops merge requests of excluded users and the ones merged before the start date of the project.
+func (u *BackgroundUpdater) filterMergeRequests(projectName string, mrs []model.MergeRequest) []model.MergeRequest {
+	cfg := u.cfg.Get()
+	startDate := cfg.Project(projectName).StartDate
+	return slices.DeleteFunc(mrs, func(mr model.MergeRequest) bool {
+		return cfg.IsExcluded(mr.Username) || mr.MergedAt.Before(startDate)
+	})
+}
+
+// filterReview drops comments and approvals of excluded users, e.g. of bots commenting on every merge request.
+func (u *BackgroundUpdater) filterReview(review model.MergeRequestReview) model.MergeRequestReview {
+	cfg := u.cfg.Get()
+	review.Events = slices.DeleteFunc(review.Events, func(event model.ReviewEvent) bool {
+		return cfg.IsExcluded(event.Username)
+	})
+	return review
+}
+
+// resolveProjects returns the configured projects together with the projects discovered by topics and groups
+// in every source. The configured projects and the ones discovered in the other sources are returned
+// even if the discovery fails in a source.
+func (u *BackgroundUpdater) resolveProjects(ctx context.Context) ([]string, error) {
+	cfg := u.cfg.Get()
+	projectNames := slices.Clone(cfg.ProjectNames)
+
+	var errs []error
+	sources := append([]config.SourceConfig{{Topics: cfg.Topics, Groups: cfg.Groups}}, cfg.Sources...)
+	for _, source := range sources {
+		if len(source.Topics) == 0 && len(source.Groups) == 0 {
 			continue
 		}
 
-		if err := u.updater.UpdateProjectCache(projectID, projectName, counts); err != nil {
-			log.Printf("Failed to update cache for project %s: %v", projectName, err)
+		discovered, err := u.gitlab.DiscoverProjects(ctx, source.Name, source.Topics, source.Groups)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("source %s: %w", cmp.Or(source.Name, "default"), err))
+			continue
 		}
+		projectNames = append(projectNames, discovered...)
 	}
+
+	slices.Sort(projectNames)
+	return slices.Compact(projectNames), errors.Join(errs...)
 }
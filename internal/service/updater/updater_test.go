@@ -0,0 +1,595 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+package updater_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"mr-metrics/internal/config"
+	"mr-metrics/internal/db"
+	"mr-metrics/internal/model"
+	"mr-metrics/internal/service/updater"
+)
+
+// waitTimeout is how long the tests wait for the updater before failing.
+const waitTimeout = 5 * time.Second
+
+var errNotFound = errors.New("not found")
+
+// fakeGitLab returns a single merge request of every project and counts the fetches.
+type fakeGitLab struct {
+	// fetch is called on every fetch with the amount of running ones including it, its error is returned
+	fetch func(ctx context.Context, projectName string, active int) error
+	// discovered are the projects found by any topic or group by source names, other sources fail
+	discovered map[string][]string
+
+	mu        sync.Mutex
+	fetched   map[string]int
+	active    int
+	maxActive int
+}
+
+func newFakeGitLab() *fakeGitLab {
+	return &fakeGitLab{fetched: make(map[string]int)}
+}
+
+func (g *fakeGitLab) GetMergedMRCounts(ctx context.Context, projectName string, _ time.Time) ([]model.MergeRequest, int, error) {
+	g.mu.Lock()
+	g.fetched[projectName]++
+	g.active++
+	g.maxActive = max(g.maxActive, g.active)
+	active := g.active
+	g.mu.Unlock()
+
+	var err error
+	if g.fetch != nil {
+		err = g.fetch(ctx, projectName, active)
+	}
+
+	g.mu.Lock()
+	g.active--
+	g.mu.Unlock()
+
+	mrs := []model.MergeRequest{{IID: 1, Username: "alice", MergedAt: time.Now()}}
+	return mrs, 1, err
+}
+
+func (g *fakeGitLab) DiscoverProjects(_ context.Context, source string, _, _ []string) ([]string, error) {
+	projectNames, ok := g.discovered[source]
+	if !ok {
+		return nil, errNotFound
+	}
+	return projectNames, nil
+}
+
+func (g *fakeGitLab) GetMergeRequestReview(context.Context, string, model.MergeRequest) (model.MergeRequestReview, error) {
+	return model.MergeRequestReview{}, nil
+}
+
+func (g *fakeGitLab) GetMergeRequestChangesCount(context.Context, string, int) (int, error) {
+	return 1, nil
+}
+
+// fetches returns how many times the project was fetched and the most fetches that ran at once.
+func (g *fakeGitLab) fetches(projectName string) (int, int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.fetched[projectName], g.maxActive
+}
+
+// fakeStore remembers when projects were updated and the sync runs.
+type fakeStore struct {
+	mu          sync.Mutex
+	lastUpdated map[string]time.Time
+	replaced    []time.Time
+	deleted     []string
+	runs        []model.SyncRun
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{lastUpdated: make(map[string]time.Time)}
+}
+
+func (s *fakeStore) UpdateProjectCache(_ context.Context, _ int, projectName string, _ []model.MergeRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastUpdated[projectName] = time.Now()
+	return nil
+}
+
+func (s *fakeStore) ReplaceProjectCache(_ context.Context, _ int, projectName string, since time.Time, _ []model.MergeRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastUpdated[projectName] = time.Now()
+	s.replaced = append(s.replaced, since)
+	return nil
+}
+
+// replacedSince returns the times the stored merge requests were replaced since.
+func (s *fakeStore) replacedSince() []time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.replaced)
+}
+
+func (s *fakeStore) GetLastUpdatedDate(_ context.Context, projectName string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lastUpdated, ok := s.lastUpdated[projectName]
+	if !ok {
+		return time.Time{}, errNotFound
+	}
+	return lastUpdated, nil
+}
+
+func (s *fakeStore) SetTrackedProjects(context.Context, []string) error {
+	return nil
+}
+
+func (s *fakeStore) DeleteUsers(_ context.Context, usernames []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deleted = append(s.deleted, usernames...)
+	return nil
+}
+
+func (s *fakeStore) AddSyncRun(_ context.Context, run model.SyncRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runs = append(s.runs, run)
+	return nil
+}
+
+// syncErrors returns the errors of the failed sync runs by project names.
+func (s *fakeStore) syncErrors() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	syncErrors := make(map[string]string)
+	for _, run := range s.runs {
+		if run.Error != "" {
+			syncErrors[run.ProjectName] = run.Error
+		}
+	}
+	return syncErrors
+}
+
+// fakeQueue is a store keeping requested syncs for the leader.
+type fakeQueue struct {
+	*fakeStore
+
+	mu       sync.Mutex
+	requests []model.SyncRequest
+}
+
+func (q *fakeQueue) AddSyncRequest(_ context.Context, request model.SyncRequest, limit int) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.requests) >= limit {
+		return false, nil
+	}
+	q.requests = append(q.requests, request)
+	return true, nil
+}
+
+func (q *fakeQueue) TakeSyncRequest(context.Context) (model.SyncRequest, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.requests) == 0 {
+		return model.SyncRequest{}, false, nil
+	}
+	request := q.requests[0]
+	q.requests = q.requests[1:]
+	return request, true, nil
+}
+
+// fakeElector is a store shared by replicas, this one becomes the leader once elected is closed.
+type fakeElector struct {
+	*fakeStore
+
+	elected chan struct{}
+}
+
+func (e *fakeElector) Lead(ctx context.Context, run func(ctx context.Context)) error {
+	select {
+	case <-e.elected:
+		run(ctx)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (e *fakeElector) TryLead(ctx context.Context, run func(ctx context.Context)) (bool, error) {
+	select {
+	case <-e.elected:
+		run(ctx)
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+func newConfig(projectNames ...string) *config.Config {
+	return &config.Config{
+		ProjectNames:       projectNames,
+		CacheTTL:           time.Hour,
+		SyncProjectTimeout: waitTimeout,
+		SyncConcurrency:    1,
+	}
+}
+
+// start runs the updater till the end of the test.
+func start(t *testing.T, u *updater.BackgroundUpdater) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	u.Start(ctx)
+	t.Cleanup(func() {
+		cancel()
+		u.Wait()
+	})
+}
+
+func waitFor(t *testing.T, what string, done func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(waitTimeout)
+	for !done() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestUpdateConcurrency(t *testing.T) {
+	t.Parallel()
+
+	const concurrency = 2
+	projectNames := []string{"group/a", "group/b", "group/c", "group/d", "group/e", "group/f"}
+	cfg := newConfig(projectNames...)
+	cfg.SyncConcurrency = concurrency
+
+	// Fetches wait till the limit is reached, so they overlap as much as they are allowed to
+	full := make(chan struct{})
+	var once sync.Once
+	gitlab := newFakeGitLab()
+	gitlab.fetch = func(ctx context.Context, _ string, active int) error {
+		if active == concurrency {
+			once.Do(func() { close(full) })
+		}
+		select {
+		case <-full:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	store := newFakeStore()
+
+	start(t, updater.New(store, gitlab, config.Static(cfg)))
+	waitFor(t, "the initial update", func() bool {
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		return len(store.lastUpdated) == len(projectNames)
+	})
+
+	for _, projectName := range projectNames {
+		if fetched, _ := gitlab.fetches(projectName); fetched != 1 {
+			t.Errorf("%s was fetched %d times, want once", projectName, fetched)
+		}
+	}
+	if _, maxActive := gitlab.fetches(""); maxActive != concurrency {
+		t.Errorf("%d projects were updated at once, want %d", maxActive, concurrency)
+	}
+}
+
+func TestUpdateStoresErrors(t *testing.T) {
+	t.Parallel()
+
+	errA := errors.New("API returned 502")
+	errC := errors.New("API returned 404")
+	gitlab := newFakeGitLab()
+	gitlab.fetch = func(_ context.Context, projectName string, _ int) error {
+		switch projectName {
+		case "group/a":
+			return errA
+		case "group/c":
+			return errC
+		default:
+			return nil
+		}
+	}
+	store := newFakeStore()
+
+	start(t, updater.New(store, gitlab, config.Static(newConfig("group/a", "group/b", "group/c"))))
+	waitFor(t, "the errors of the failed projects", func() bool {
+		return len(store.syncErrors()) == 2
+	})
+
+	// The failed projects don't stop the others
+	waitFor(t, "the update of group/b", func() bool {
+		_, err := store.GetLastUpdatedDate(t.Context(), "group/b")
+		return err == nil
+	})
+
+	syncErrors := store.syncErrors()
+	for projectName, want := range map[string]error{"group/a": errA, "group/c": errC} {
+		if got := syncErrors[projectName]; got != "failed to fetch data: "+want.Error() {
+			t.Errorf("error of %s = %q, want %q", projectName, got, want)
+		}
+	}
+}
+
+func TestTickDuringUpdateDoesNotStartAnother(t *testing.T) {
+	t.Parallel()
+
+	const projectName = "group/slow"
+	cfg := newConfig(projectName)
+	cfg.CacheTTL = 10 * time.Millisecond
+	cfg.SyncConcurrency = 4
+
+	release := make(chan struct{})
+	gitlab := newFakeGitLab()
+	gitlab.fetch = func(ctx context.Context, _ string, _ int) error {
+		select {
+		case <-release:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	start(t, updater.New(newFakeStore(), gitlab, config.Static(cfg)))
+	waitFor(t, "the initial update", func() bool {
+		fetched, _ := gitlab.fetches(projectName)
+		return fetched == 1
+	})
+
+	// Several ticks pass while the initial update is running
+	time.Sleep(10 * cfg.CacheTTL)
+	if fetched, _ := gitlab.fetches(projectName); fetched != 1 {
+		t.Errorf("%s was fetched %d times during a single update, want once", projectName, fetched)
+	}
+
+	close(release)
+	waitFor(t, "the next update", func() bool {
+		fetched, _ := gitlab.fetches(projectName)
+		return fetched >= 2
+	})
+	if _, maxActive := gitlab.fetches(projectName); maxActive != 1 {
+		t.Errorf("%d updates ran at once, want 1", maxActive)
+	}
+}
+
+func TestEnqueue(t *testing.T) {
+	t.Parallel()
+
+	const projectName = "group/web"
+	gitlab := newFakeGitLab()
+	store := newFakeStore()
+	u := updater.New(store, gitlab, config.Static(newConfig(projectName)))
+
+	if err := u.Enqueue(t.Context(), model.SyncRequest{}); !errors.Is(err, updater.ErrNotLeader) {
+		t.Fatalf("Enqueue before Start returned %v, want %v", err, updater.ErrNotLeader)
+	}
+
+	start(t, u)
+	waitFor(t, "the initial update", func() bool {
+		fetched, _ := gitlab.fetches(projectName)
+		return fetched == 1
+	})
+
+	since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := u.Enqueue(t.Context(), model.SyncRequest{ProjectName: projectName, Since: since, Rebuild: true}); err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+	waitFor(t, "the requested rebuild", func() bool {
+		replaced := store.replacedSince()
+		return len(replaced) == 1 && replaced[0].Equal(since)
+	})
+}
+
+func TestUpdateOnlyWhileLeading(t *testing.T) {
+	t.Parallel()
+
+	const projectName = "group/web"
+	gitlab := newFakeGitLab()
+	elector := &fakeElector{fakeStore: newFakeStore(), elected: make(chan struct{})}
+	u := updater.New(elector, gitlab, config.Static(newConfig(projectName)))
+
+	start(t, u)
+	time.Sleep(10 * time.Millisecond)
+	if fetched, _ := gitlab.fetches(projectName); fetched != 0 {
+		t.Errorf("%s was fetched %d times by a replica that isn't the leader", projectName, fetched)
+	}
+	if err := u.Enqueue(t.Context(), model.SyncRequest{}); !errors.Is(err, updater.ErrNotLeader) {
+		t.Errorf("Enqueue on a replica that isn't the leader returned %v, want %v", err, updater.ErrNotLeader)
+	}
+
+	close(elector.elected)
+	waitFor(t, "the update by the leader", func() bool {
+		fetched, _ := gitlab.fetches(projectName)
+		return fetched == 1
+	})
+}
+
+func TestReconcile(t *testing.T) {
+	t.Parallel()
+
+	cfg := newConfig("group/web")
+	cfg.ReconcileInterval = 10 * time.Millisecond
+	cfg.ReconcileWindow = 7 * 24 * time.Hour
+	store := newFakeStore()
+
+	before := time.Now()
+	start(t, updater.New(store, newFakeGitLab(), config.Static(cfg)))
+
+	// The merge requests merged within the window are replaced with the fetched ones
+	waitFor(t, "a reconciliation", func() bool {
+		return len(store.replacedSince()) > 0
+	})
+	if since := store.replacedSince()[0]; since.Before(before.Add(-cfg.ReconcileWindow)) || since.After(time.Now()) {
+		t.Errorf("merge requests were replaced since %v, want since %v ago", since, cfg.ReconcileWindow)
+	}
+}
+
+func TestReconcileAfterRestart(t *testing.T) {
+	t.Parallel()
+
+	cfg := newConfig("group/web")
+	cfg.ReconcileInterval = 10 * time.Millisecond
+	cfg.ReconcileWindow = 7 * 24 * time.Hour
+	store := newFakeStore()
+	u := updater.New(store, newFakeGitLab(), config.Static(cfg))
+
+	// The first term ends like a lost leadership does
+	ctx, cancel := context.WithCancel(t.Context())
+	u.Start(ctx)
+	waitFor(t, "a reconciliation", func() bool {
+		return len(store.replacedSince()) > 0
+	})
+	cancel()
+	u.Wait()
+
+	replaced := len(store.replacedSince())
+	start(t, u)
+	waitFor(t, "a reconciliation after the restart", func() bool {
+		return len(store.replacedSince()) > replaced
+	})
+}
+
+func TestSyncJoinsErrors(t *testing.T) {
+	t.Parallel()
+
+	errA := errors.New("API returned 502")
+	errC := errors.New("API returned 404")
+	gitlab := newFakeGitLab()
+	gitlab.fetch = func(_ context.Context, projectName string, _ int) error {
+		switch projectName {
+		case "group/a":
+			return errA
+		case "group/c":
+			return errC
+		default:
+			return nil
+		}
+	}
+	store := newFakeStore()
+
+	u := updater.New(store, gitlab, config.Static(newConfig("group/a", "group/b", "group/c")))
+	err := u.Sync(t.Context(), model.SyncRequest{})
+	if !errors.Is(err, errA) || !errors.Is(err, errC) {
+		t.Fatalf("Sync returned %v, want both %v and %v", err, errA, errC)
+	}
+
+	// The failed projects don't stop the others
+	if _, err := store.GetLastUpdatedDate(t.Context(), "group/b"); err != nil {
+		t.Errorf("group/b wasn't updated: %v", err)
+	}
+	if syncErrors := store.syncErrors(); len(store.runs) != 3 || len(syncErrors) != 2 {
+		t.Errorf("sync runs = %+v, want 3 of them with 2 failed", store.runs)
+	}
+}
+
+func TestSyncDiscoversProjectsOfEverySource(t *testing.T) {
+	t.Parallel()
+
+	gitlab := newFakeGitLab()
+	gitlab.discovered = map[string][]string{"": {"group/web"}, "oss": {"oss:org/library"}}
+	store := newFakeStore()
+
+	cfg := newConfig("group/api")
+	cfg.Topics = []string{"metrics"}
+	cfg.Sources = []config.SourceConfig{
+		{Name: "oss", Groups: []string{"org"}},
+		{Name: "broken", Topics: []string{"metrics"}},
+		{Name: "manual"},
+	}
+	err := updater.New(store, gitlab, config.Static(cfg)).Sync(t.Context(), model.SyncRequest{})
+	if !errors.Is(err, errNotFound) {
+		t.Errorf("Sync returned %v, want %v", err, errNotFound)
+	}
+
+	// A source failing to discover doesn't stop the others
+	for _, projectName := range []string{"group/api", "group/web", "oss:org/library"} {
+		if fetched, _ := gitlab.fetches(projectName); fetched != 1 {
+			t.Errorf("%s was fetched %d times, want once", projectName, fetched)
+		}
+	}
+}
+
+func TestSyncDeletesExcludedUsers(t *testing.T) {
+	t.Parallel()
+
+	store := db.NewMemoryStore(false)
+	cfg := newConfig("group/web")
+	if err := updater.New(store, newFakeGitLab(), config.Static(cfg)).Sync(t.Context(), model.SyncRequest{}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	// alice is excluded after their merge request was stored
+	excluded := newConfig("group/web")
+	excluded.ExcludedUsers = []string{"alice"}
+	if err := updater.New(store, newFakeGitLab(), config.Static(excluded)).Sync(t.Context(), model.SyncRequest{}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	stats, err := store.GetAggregatedDataForDate(t.Context(), []string{"group/web"}, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GetAggregatedDataForDate failed: %v", err)
+	}
+	if len(stats.Developers) != 0 {
+		t.Errorf("Developers = %v, want none", stats.Developers)
+	}
+}
+
+func TestTickSkipsProjectsNotDue(t *testing.T) {
+	t.Parallel()
+
+	const fresh, stale = "group/fresh", "group/stale"
+	cfg := newConfig(fresh, stale)
+	cfg.Projects = map[string]config.ProjectConfig{
+		fresh: {SyncInterval: time.Hour},
+		stale: {SyncInterval: 10 * time.Millisecond},
+	}
+	gitlab := newFakeGitLab()
+
+	start(t, updater.New(newFakeStore(), gitlab, config.Static(cfg)))
+
+	// Projects are checked in order, so fresh was skipped by the time stale is synced again
+	waitFor(t, "the updates of "+stale, func() bool {
+		fetched, _ := gitlab.fetches(stale)
+		return fetched >= 3
+	})
+	if fetched, _ := gitlab.fetches(fresh); fetched != 1 {
+		t.Errorf("%s was fetched %d times, want only by the initial sync", fresh, fetched)
+	}
+}
+
+func TestEnqueueStoresRequests(t *testing.T) {
+	t.Parallel()
+
+	// Requests are accepted by any replica if the store keeps them for the leader
+	queue := &fakeQueue{fakeStore: newFakeStore()}
+	u := updater.New(queue, newFakeGitLab(), config.Static(newConfig("group/web")))
+
+	var err error
+	for err == nil {
+		err = u.Enqueue(t.Context(), model.SyncRequest{ProjectName: "group/web"})
+	}
+	if !errors.Is(err, updater.ErrQueueFull) {
+		t.Fatalf("Enqueue returned %v, want %v", err, updater.ErrQueueFull)
+	}
+	if len(queue.requests) == 0 {
+		t.Error("no requests were stored")
+	}
+}
@@ -6,13 +6,19 @@ package web
 
 import (
 	"embed"
+	"errors"
+	"fmt"
 	"html/template"
+	"mr-metrics/internal/consts"
 	"net/http"
+	"time"
 )
 
 //go:embed templates/*.gohtml style.css
 var fs embed.FS
 
+const percents = 100
+
 func templateFrom(funcMap template.FuncMap, filenames ...string) *template.Template {
 	// NOTE(danilax86): head.gohtml is the default template that will be used in every ever made template.
 	filenames = append(filenames, "head")
@@ -27,7 +33,34 @@ func TemplateExec(w http.ResponseWriter, t *template.Template, data any) error {
 }
 
 func TemplateStats() *template.Template {
-	return templateFrom(template.FuncMap{"sum": mapSumFunc}, "stats")
+	return templateFrom(template.FuncMap{"sum": mapSumFunc}, "stats", "tabs")
+}
+
+func TemplateReviewers() *template.Template {
+	return templateFrom(template.FuncMap{}, "reviewers", "tabs")
+}
+
+func TemplateLeadTime() *template.Template {
+	return templateFrom(template.FuncMap{"duration": durationFunc, "dict": dictFunc}, "leadtime")
+}
+
+func TemplateReviews() *template.Template {
+	return templateFrom(template.FuncMap{"duration": durationFunc}, "reviews")
+}
+
+func TemplateDeveloper() *template.Template {
+	return templateFrom(template.FuncMap{"sum": mapSumFunc, "trend": trendFunc}, "developer", "history")
+}
+
+func TemplateProject() *template.Template {
+	return templateFrom(
+		template.FuncMap{"sum": mapSumFunc, "percent": percentFunc, "duration": durationFunc},
+		"project", "history", "runs",
+	)
+}
+
+func TemplateStatus() *template.Template {
+	return templateFrom(template.FuncMap{"duration": durationFunc}, "status", "runs")
 }
 
 func mapSumFunc(m map[string]int) int {
@@ -38,6 +71,58 @@ func mapSumFunc(m map[string]int) int {
 	return sum
 }
 
+// trendFunc formats the change of a value compared to the previous one in percents.
+func trendFunc(current, previous int) string {
+	if previous == 0 {
+		if current == 0 {
+			return "no change"
+		}
+		return "new activity"
+	}
+	return fmt.Sprintf("%+.0f%%", float64(current-previous)/float64(previous)*percents)
+}
+
+// percentFunc formats a share from 0 to 1 in percents.
+func percentFunc(share float64) string {
+	return fmt.Sprintf("%.0f%%", share*percents)
+}
+
+// durationFunc formats a duration rounding it to the two most significant units, e.g. "2d 3h" or "5m 10s".
+func durationFunc(d time.Duration) string {
+	d = d.Round(time.Second)
+
+	days := d / consts.OneDay
+	hours := (d % consts.OneDay) / time.Hour
+	minutes := (d % time.Hour) / time.Minute
+	seconds := (d % time.Minute) / time.Second
+
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	default:
+		return fmt.Sprintf("%dm %ds", minutes, seconds)
+	}
+}
+
+// dictFunc builds a map from key and value pairs, so several values can be passed to a template.
+func dictFunc(pairs ...any) (map[string]any, error) {
+	if len(pairs)%2 != 0 {
+		return nil, errors.New("dict expects key and value pairs")
+	}
+
+	dict := make(map[string]any, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("dict key %v is not a string", pairs[i])
+		}
+		dict[key] = pairs[i+1]
+	}
+	return dict, nil
+}
+
 // GetStyleCSS returns the embedded style.css file content.
 func GetStyleCSS() ([]byte, error) {
 	return fs.ReadFile("style.css")
@@ -0,0 +1,13 @@
+// SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+//
+// SPDX-License-Identifier: MIT
+
+// Package migrations embeds the SQL migrations of every supported database, so the binary runs from any directory.
+package migrations
+
+import "embed"
+
+// FS keeps the migrations of every database in the directory named after it.
+//
+//go:embed postgres/*.sql sqlite/*.sql
+var FS embed.FS
@@ -0,0 +1,5 @@
+-- SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+--
+-- SPDX-License-Identifier: MIT
+
+DROP TABLE IF EXISTS merge_requests;
@@ -0,0 +1,22 @@
+-- SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+--
+-- SPDX-License-Identifier: MIT
+
+CREATE TABLE IF NOT EXISTS merge_requests
+(
+    project_id    INT          NOT NULL,
+    iid           INT          NOT NULL,
+    title         TEXT         NOT NULL,
+    username      VARCHAR(255) NOT NULL,
+    source_branch VARCHAR(255) NOT NULL,
+    target_branch VARCHAR(255) NOT NULL,
+    labels        TEXT[]       NOT NULL DEFAULT '{}',
+    web_url       TEXT         NOT NULL,
+    created_at    TIMESTAMP    NOT NULL,
+    merged_at     TIMESTAMP    NOT NULL,
+    PRIMARY KEY (project_id, iid),
+    FOREIGN KEY (project_id) REFERENCES projects (project_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_merge_requests_username ON merge_requests (username);
+CREATE INDEX IF NOT EXISTS idx_merge_requests_merged_at ON merge_requests (merged_at);
@@ -0,0 +1,8 @@
+-- SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+--
+-- SPDX-License-Identifier: MIT
+
+DROP TABLE IF EXISTS review_events;
+
+ALTER TABLE merge_requests
+    DROP COLUMN IF EXISTS review_rounds;
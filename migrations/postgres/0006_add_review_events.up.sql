@@ -0,0 +1,19 @@
+-- SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+--
+-- SPDX-License-Identifier: MIT
+
+ALTER TABLE merge_requests
+    ADD COLUMN IF NOT EXISTS review_rounds INT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS review_events
+(
+    project_id INT          NOT NULL,
+    mr_iid     INT          NOT NULL,
+    username   VARCHAR(255) NOT NULL,
+    kind       VARCHAR(16)  NOT NULL,
+    created_at TIMESTAMP    NOT NULL,
+    FOREIGN KEY (project_id, mr_iid) REFERENCES merge_requests (project_id, iid) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_review_events_mr ON review_events (project_id, mr_iid);
+CREATE INDEX IF NOT EXISTS idx_review_events_username ON review_events (username);
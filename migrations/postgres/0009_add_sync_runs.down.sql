@@ -0,0 +1,20 @@
+-- SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+--
+-- SPDX-License-Identifier: MIT
+
+CREATE TABLE IF NOT EXISTS sync_errors
+(
+    id           SERIAL PRIMARY KEY,
+    project_name VARCHAR(255) NOT NULL,
+    occurred_at  TIMESTAMP    NOT NULL DEFAULT NOW(),
+    message      TEXT         NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_errors_project_name ON sync_errors (project_name, occurred_at);
+
+INSERT INTO sync_errors (project_name, occurred_at, message)
+SELECT project_name, finished_at, error
+FROM sync_runs
+WHERE error IS NOT NULL;
+
+DROP TABLE IF EXISTS sync_runs;
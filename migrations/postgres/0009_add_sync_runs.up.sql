@@ -0,0 +1,25 @@
+-- SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+--
+-- SPDX-License-Identifier: MIT
+
+CREATE TABLE IF NOT EXISTS sync_runs
+(
+    id             SERIAL PRIMARY KEY,
+    project_name   VARCHAR(255) NOT NULL,
+    started_at     TIMESTAMP    NOT NULL,
+    finished_at    TIMESTAMP    NOT NULL,
+    -- NULL if the whole history was fetched
+    since          TIMESTAMP,
+    merge_requests INT          NOT NULL DEFAULT 0,
+    -- NULL if the sync succeeded
+    error          TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_runs_project_name ON sync_runs (project_name, started_at);
+
+-- Sync errors are kept as failed runs
+INSERT INTO sync_runs (project_name, started_at, finished_at, error)
+SELECT project_name, occurred_at, occurred_at, message
+FROM sync_errors;
+
+DROP TABLE IF EXISTS sync_errors;
@@ -0,0 +1,6 @@
+-- SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+--
+-- SPDX-License-Identifier: MIT
+
+ALTER TABLE merge_requests
+    DROP COLUMN IF EXISTS is_revert;
@@ -0,0 +1,7 @@
+-- SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+--
+-- SPDX-License-Identifier: MIT
+
+-- Reverts are merged like any other merge request, but they may be excluded from the counts
+ALTER TABLE merge_requests
+    ADD COLUMN IF NOT EXISTS is_revert BOOLEAN GENERATED ALWAYS AS (title LIKE 'Revert%') STORED;
@@ -0,0 +1,12 @@
+-- SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+--
+-- SPDX-License-Identifier: MIT
+
+ALTER TABLE projects
+    ALTER COLUMN project_id DROP DEFAULT;
+DROP SEQUENCE IF EXISTS projects_project_id_seq;
+
+DROP INDEX IF EXISTS idx_projects_source_gitlab_id;
+ALTER TABLE projects
+    DROP COLUMN IF EXISTS gitlab_id,
+    DROP COLUMN IF EXISTS source;
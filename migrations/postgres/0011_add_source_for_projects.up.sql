@@ -0,0 +1,20 @@
+-- SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+--
+-- SPDX-License-Identifier: MIT
+
+-- Projects of different GitLab instances may have the same IDs, so the ID of a project in its GitLab
+-- is stored together with the source, and project_id becomes an internal ID generated on insert.
+ALTER TABLE projects
+    ADD COLUMN IF NOT EXISTS source    VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS gitlab_id INT          NOT NULL DEFAULT 0;
+
+UPDATE projects
+SET gitlab_id = project_id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_projects_source_gitlab_id ON projects (source, gitlab_id);
+
+CREATE SEQUENCE IF NOT EXISTS projects_project_id_seq OWNED BY projects.project_id;
+SELECT setval('projects_project_id_seq', COALESCE(MAX(project_id), 0) + 1, false)
+FROM projects;
+ALTER TABLE projects
+    ALTER COLUMN project_id SET DEFAULT nextval('projects_project_id_seq');
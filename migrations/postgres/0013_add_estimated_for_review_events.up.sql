@@ -0,0 +1,17 @@
+-- SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+--
+-- SPDX-License-Identifier: MIT
+
+-- Approvals without a system note have no time of their own, they are stored at the merge time
+-- and are left out of the time to approval.
+ALTER TABLE review_events
+    ADD COLUMN estimated BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Such approvals were stored at the merge time before
+UPDATE review_events
+SET estimated = TRUE
+WHERE kind = 'approval'
+  AND created_at = (SELECT mr.merged_at
+                    FROM merge_requests mr
+                    WHERE mr.project_id = review_events.project_id
+                      AND mr.iid = review_events.mr_iid);
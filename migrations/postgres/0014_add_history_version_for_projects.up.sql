@@ -0,0 +1,9 @@
+-- SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+--
+-- SPDX-License-Identifier: MIT
+
+-- Projects stored with an older version of the fields fetched from GitLab are fetched from scratch once,
+-- a migration adding such a field bumps historyVersion in the code instead of resetting last_updated.
+-- Existing projects get version 0, so they are fetched from scratch once, as the resets before did.
+ALTER TABLE projects
+    ADD COLUMN IF NOT EXISTS history_version INT NOT NULL DEFAULT 0;
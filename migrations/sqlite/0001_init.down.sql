@@ -0,0 +1,8 @@
+-- SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+--
+-- SPDX-License-Identifier: MIT
+
+DROP TABLE IF EXISTS sync_runs;
+DROP TABLE IF EXISTS review_events;
+DROP TABLE IF EXISTS merge_requests;
+DROP TABLE IF EXISTS projects;
@@ -0,0 +1,64 @@
+-- SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+--
+-- SPDX-License-Identifier: MIT
+
+CREATE TABLE IF NOT EXISTS projects
+(
+    project_id   INTEGER PRIMARY KEY,
+    project_name TEXT      NOT NULL,
+    last_updated TIMESTAMP NOT NULL,
+    tracked      BOOLEAN   NOT NULL DEFAULT TRUE
+);
+
+-- Counts are derived from merge requests on every query, so there is no daily counts table
+CREATE TABLE IF NOT EXISTS merge_requests
+(
+    project_id    INTEGER   NOT NULL,
+    iid           INTEGER   NOT NULL,
+    title         TEXT      NOT NULL,
+    username      TEXT      NOT NULL,
+    source_branch TEXT      NOT NULL,
+    target_branch TEXT      NOT NULL,
+    -- JSON array of labels
+    labels        TEXT      NOT NULL DEFAULT '[]',
+    web_url       TEXT      NOT NULL,
+    created_at    TIMESTAMP NOT NULL,
+    merged_at     TIMESTAMP NOT NULL,
+    review_rounds INTEGER   NOT NULL DEFAULT 0,
+    changes_count INTEGER   NOT NULL DEFAULT 0,
+    -- NOTE(danilax86): LIKE is case-insensitive in SQLite
+    is_revert     BOOLEAN GENERATED ALWAYS AS (substr(title, 1, 6) = 'Revert') VIRTUAL,
+    PRIMARY KEY (project_id, iid),
+    FOREIGN KEY (project_id) REFERENCES projects (project_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_merge_requests_username ON merge_requests (username);
+CREATE INDEX IF NOT EXISTS idx_merge_requests_merged_at ON merge_requests (merged_at);
+
+CREATE TABLE IF NOT EXISTS review_events
+(
+    project_id INTEGER   NOT NULL,
+    mr_iid     INTEGER   NOT NULL,
+    username   TEXT      NOT NULL,
+    kind       TEXT      NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (project_id, mr_iid) REFERENCES merge_requests (project_id, iid) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_review_events_mr ON review_events (project_id, mr_iid);
+CREATE INDEX IF NOT EXISTS idx_review_events_username ON review_events (username);
+
+CREATE TABLE IF NOT EXISTS sync_runs
+(
+    id             INTEGER PRIMARY KEY AUTOINCREMENT,
+    project_name   TEXT      NOT NULL,
+    started_at     TIMESTAMP NOT NULL,
+    finished_at    TIMESTAMP NOT NULL,
+    -- NULL if the whole history was fetched
+    since          TIMESTAMP,
+    merge_requests INTEGER   NOT NULL DEFAULT 0,
+    -- NULL if the sync succeeded
+    error          TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_runs_project_name ON sync_runs (project_name, started_at);
@@ -0,0 +1,9 @@
+-- SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+--
+-- SPDX-License-Identifier: MIT
+
+DROP INDEX IF EXISTS idx_projects_source_gitlab_id;
+ALTER TABLE projects
+    DROP COLUMN gitlab_id;
+ALTER TABLE projects
+    DROP COLUMN source;
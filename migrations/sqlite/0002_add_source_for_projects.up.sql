@@ -0,0 +1,15 @@
+-- SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+--
+-- SPDX-License-Identifier: MIT
+
+-- Projects of different GitLab instances may have the same IDs, so the ID of a project in its GitLab
+-- is stored together with the source, and project_id becomes an internal ID generated on insert.
+ALTER TABLE projects
+    ADD COLUMN source TEXT NOT NULL DEFAULT '';
+ALTER TABLE projects
+    ADD COLUMN gitlab_id INTEGER NOT NULL DEFAULT 0;
+
+UPDATE projects
+SET gitlab_id = project_id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_projects_source_gitlab_id ON projects (source, gitlab_id);
@@ -0,0 +1,15 @@
+-- SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+--
+-- SPDX-License-Identifier: MIT
+
+-- Syncs requested through the API on any instance wait here for the leader to run them
+CREATE TABLE IF NOT EXISTS sync_requests
+(
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    -- Empty to sync all projects
+    project_name TEXT      NOT NULL DEFAULT '',
+    -- NULL to fetch merge requests updated since the last update
+    since        TIMESTAMP,
+    rebuild      BOOLEAN   NOT NULL DEFAULT FALSE,
+    requested_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,12 @@
+-- SPDX-FileCopyrightText: 2025 Danila Gorelko <hello@danilax86.space>
+--
+-- SPDX-License-Identifier: MIT
+
+-- Projects stored with an older version of the fields fetched from GitLab are fetched from scratch once,
+-- a migration adding such a field bumps historyVersion in the code instead of resetting last_updated.
+ALTER TABLE projects
+    ADD COLUMN history_version INTEGER NOT NULL DEFAULT 0;
+
+-- SQLite databases stored every field from the start, so their projects are up to date
+UPDATE projects
+SET history_version = 1;